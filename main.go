@@ -1,31 +1,61 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/Luzifer/doc-render/pkg/api"
 	"github.com/Luzifer/doc-render/pkg/frontend"
+	"github.com/Luzifer/doc-render/pkg/jobs"
+	"github.com/Luzifer/doc-render/pkg/latex"
 	"github.com/Luzifer/doc-render/pkg/persist/k8s"
 	"github.com/Luzifer/doc-render/pkg/persist/mem"
 	"github.com/Luzifer/doc-render/pkg/persist/redis"
+	"github.com/Luzifer/doc-render/pkg/rendercache/disk"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	jobsMem "github.com/Luzifer/doc-render/pkg/jobs/mem"
+	cacheMem "github.com/Luzifer/doc-render/pkg/rendercache/mem"
 	httpHelper "github.com/Luzifer/go_helpers/v2/http"
 	"github.com/Luzifer/rconfig/v2"
 )
 
+const (
+	bytesPerMiB     = 1024 * 1024
+	shutdownTimeout = 10 * time.Second
+)
+
 var (
 	cfg = struct {
-		Listen          string `flag:"listen" default:":3000" description:"Port/IP to listen on"`
-		LogLevel        string `flag:"log-level" default:"info" description:"Log level (debug, info, warn, error, fatal)"`
-		PersistTo       string `flag:"persist-to" default:"disable" description:"Where to store server-side templates (disable, k8s, mem, redis)"`
-		SourceSetFolder string `flag:"source-set-folder" default:"source" description:"Where to find the templates to render"`
-		TexAPIJobURL    string `flag:"tex-api-job-url" default:"" description:"Where to find the job endpoint of the TeX-API"`
-		VersionAndExit  bool   `flag:"version" default:"false" description:"Prints current version and exits"`
+		Cache             string        `flag:"cache" default:"disable" description:"Where to cache rendered documents (disable, disk, mem)"`
+		CacheDir          string        `flag:"cache-dir" default:"" description:"Directory to store the disk cache in (defaults to a directory in the system temp dir)"`
+		CacheMaxSizeMiB   int64         `flag:"cache-max-size" default:"256" description:"Maximum size of the cache in MiB"`
+		CacheTTL          time.Duration `flag:"cache-ttl" default:"24h" description:"How long to keep rendered documents in the cache"`
+		JobQueueSize      int           `flag:"job-queue-size" default:"100" description:"How many asynchronous render jobs may wait for a worker"`
+		JobRetention      time.Duration `flag:"job-retention" default:"1h" description:"How long to keep finished asynchronous render jobs"`
+		JobTimeout        time.Duration `flag:"job-timeout" default:"30m" description:"Timeout for an asynchronous render job (0 to disable)"`
+		JobWorkers        int           `flag:"job-workers" default:"2" description:"How many asynchronous render jobs to execute in parallel"`
+		Listen            string        `flag:"listen" default:":3000" description:"Port/IP to listen on"`
+		LogLevel          string        `flag:"log-level" default:"info" description:"Log level (debug, info, warn, error, fatal)"`
+		OutputFile        string        `flag:"output-file" default:"-" description:"Where to write the PDF to when using the render command (- for stdout)"`
+		PersistTo         string        `flag:"persist-to" default:"disable" description:"Where to store server-side templates (disable, k8s, mem, redis)"`
+		RecipientsFile    string        `flag:"recipients-file" default:"" description:"Recipient CSV to use when using the render command"`
+		RenderConcurrency int           `flag:"render-concurrency" default:"4" description:"How many documents of a serial letter to render in parallel"`
+		RenderEngine      string        `flag:"render-engine" default:"tex-api" description:"Engine to render documents with (tex-api, latexmk, latexmk-lualatex, latexmk-xelatex, lualatex, pdflatex, xelatex)"`
+		RenderPasses      int           `flag:"render-passes" default:"2" description:"How often to run local engines (ignored for tex-api and latexmk)"`
+		RenderTimeout     time.Duration `flag:"render-timeout" default:"1m" description:"Timeout for a local engine to render the document"`
+		Sandbox           bool          `flag:"sandbox" default:"false" description:"Disable raw LaTeX and reject values containing dangerous control sequences for all source-sets"`
+		SourceSetFolder   string        `flag:"source-set-folder" default:"source" description:"Where to find the templates to render"`
+		TexAPIJobURL      string        `flag:"tex-api-job-url" default:"" description:"Where to find the job endpoint of the TeX-API"`
+		ValuesFile        string        `flag:"values-file" default:"-" description:"JSON file to read the values from when using the render command (- for stdin)"`
+		VersionAndExit    bool          `flag:"version" default:"false" description:"Prints current version and exits"`
 	}{}
 
 	version = "dev"
@@ -46,6 +76,23 @@ func initApp() error {
 	return nil
 }
 
+func getRenderer() (latex.Renderer, error) {
+	switch {
+	case cfg.RenderEngine == "tex-api":
+		return latex.NewTexAPIRenderer(cfg.TexAPIJobURL), nil
+
+	case latex.IsLocalEngine(cfg.RenderEngine):
+		renderer, err := latex.NewLocalRenderer(cfg.RenderEngine, cfg.RenderPasses, cfg.RenderTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating local renderer")
+		}
+		return renderer, nil
+
+	default:
+		return nil, errors.Errorf("invalid render-engine %q", cfg.RenderEngine)
+	}
+}
+
 func main() {
 	var err error
 	if err = initApp(); err != nil {
@@ -57,11 +104,62 @@ func main() {
 		os.Exit(0)
 	}
 
+	renderer, err := getRenderer()
+	if err != nil {
+		logrus.WithError(err).Fatal("creating renderer")
+	}
+
+	if args := rconfig.Args(); len(args) > 1 {
+		switch args[1] {
+		case "render":
+			if len(args) != 3 { //nolint:mnd
+				logrus.Fatal("usage: doc-render [options] render <source-set>")
+			}
+
+			if err = runRender(renderer, args[2]); err != nil {
+				logrus.WithError(err).Fatal("rendering document")
+			}
+
+			return
+
+		default:
+			logrus.Fatalf("unknown command %q", args[1])
+		}
+	}
+
 	r := mux.NewRouter()
 
+	jobManager := jobs.NewManager(jobsMem.New(cfg.JobRetention), cfg.JobWorkers, cfg.JobQueueSize, cfg.JobTimeout)
+
 	apiOpts := []api.Option{
+		api.WithJobManager(jobManager),
+		api.WithRenderConcurrency(cfg.RenderConcurrency),
+		api.WithRenderer(renderer),
+		api.WithSandbox(cfg.Sandbox),
 		api.WithSourceSetDir(cfg.SourceSetFolder),
-		api.WithTexAPIJobURL(cfg.TexAPIJobURL),
+	}
+
+	switch cfg.Cache {
+	case "disable", "":
+		// Nothing to do, caching is disabled
+
+	case "disk":
+		dir := cfg.CacheDir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "doc-render-cache")
+		}
+
+		cache, err := disk.New(dir, cfg.CacheMaxSizeMiB*bytesPerMiB, cfg.CacheTTL)
+		if err != nil {
+			logrus.WithError(err).Fatal("creating disk cache")
+		}
+		apiOpts = append(apiOpts, api.WithCache(cache))
+
+	case "mem":
+		apiOpts = append(apiOpts, api.WithCache(cacheMem.New(cfg.CacheMaxSizeMiB*bytesPerMiB, cfg.CacheTTL)))
+
+	default:
+		logrus.Fatal("invalid cache backend")
 	}
 
 	switch cfg.PersistTo {
@@ -104,8 +202,29 @@ func main() {
 		ReadHeaderTimeout: time.Second,
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+
+		// Cancel running jobs before waiting for open requests
+		jobManager.Close()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logrus.WithError(err).Error("shutting down HTTP server")
+		}
+	}()
+
 	logrus.WithField("version", version).WithField("addr", cfg.Listen).Info("doc-render started")
-	if err = srv.ListenAndServe(); err != nil {
+	if err = srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logrus.WithError(err).Fatal("listening for HTTP traffic")
 	}
+
+	<-shutdownDone
 }
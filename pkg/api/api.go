@@ -3,9 +3,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/Luzifer/doc-render/pkg/jobs"
+	"github.com/Luzifer/doc-render/pkg/latex"
 	"github.com/Luzifer/doc-render/pkg/persist"
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/Luzifer/doc-render/pkg/rendercache"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -17,14 +22,34 @@ type (
 
 	// Server represents the API server holding the methods for the routes
 	Server struct {
-		persistBackend persist.Backend
-		sourceSetDir   string
-		texAPIJobURL   string
+		cache             rendercache.Cache
+		jobs              *jobs.Manager
+		persistBackend    persist.Backend
+		renderConcurrency int
+		renderer          latex.Renderer
+		sandbox           bool
+		sourceSetDir      string
+	}
+
+	recipientsPayload struct {
+		// Format contains the format of the Data, detected when empty
+		Format string `json:"format,omitempty"`
+		// Data contains the recipient data, binary formats (XLSX) must be
+		// sent base64 encoded
+		Data string `json:"data"`
+		// Encoding is set to "base64" when the Data is base64 encoded
+		Encoding string `json:"encoding,omitempty"`
 	}
 
 	renderRequest struct {
-		FoxCSV *string        `json:"foxCSV,omitempty"`
-		Values map[string]any `json:"values"`
+		// Assets contains base64 encoded files to make available to the
+		// template, i.e. images referenced in Markdown
+		Assets           map[string]string     `json:"assets,omitempty"`
+		FileNamePattern  string                `json:"fileNamePattern,omitempty"`
+		FoxCSV           *string               `json:"foxCSV,omitempty"`
+		RecipientOptions *recipientcsv.Options `json:"recipientOptions,omitempty"`
+		Recipients       *recipientsPayload    `json:"recipients,omitempty"`
+		Values           map[string]any        `json:"values"`
 	}
 )
 
@@ -39,19 +64,48 @@ func New(opts ...Option) *Server {
 	return s
 }
 
+// WithCache configures a cache to serve identical render requests from
+func WithCache(cache rendercache.Cache) Option {
+	return func(s *Server) { s.cache = cache }
+}
+
+// WithJobManager configures the manager to execute asynchronous
+// render jobs with
+func WithJobManager(m *jobs.Manager) Option {
+	return func(s *Server) { s.jobs = m }
+}
+
 // WithPersistBackend configures a backend to persist templates in
 func WithPersistBackend(backend persist.Backend) Option {
 	return func(s *Server) { s.persistBackend = backend }
 }
 
+// WithRenderConcurrency configures how many documents of a serial
+// letter are rendered in parallel
+func WithRenderConcurrency(n int) Option {
+	return func(s *Server) { s.renderConcurrency = n }
+}
+
+// WithRenderer configures the renderer to compile the documents with
+func WithRenderer(renderer latex.Renderer) Option {
+	return func(s *Server) { s.renderer = renderer }
+}
+
+// WithSandbox enables the sandbox for all source-sets (see
+// latex.RenderOpts.Sandbox)
+func WithSandbox(enabled bool) Option {
+	return func(s *Server) { s.sandbox = enabled }
+}
+
 // WithSourceSetDir configures the base-path of the source-set directory
 func WithSourceSetDir(dir string) Option {
 	return func(s *Server) { s.sourceSetDir = dir }
 }
 
-// WithTexAPIJobURL configures the URL of the TeX-API `/job` endpoint
+// WithTexAPIJobURL configures the renderer to use the TeX-API
+// with the given URL of its `/job` endpoint
 func WithTexAPIJobURL(url string) Option {
-	return func(s *Server) { s.texAPIJobURL = url }
+	return func(s *Server) { s.renderer = latex.NewTexAPIRenderer(url) }
 }
 
 // Register adds the routes to the router using a sub-router on the `/api` prefix
@@ -61,12 +115,24 @@ func (s Server) Register(r *mux.Router) {
 
 	sr.HandleFunc("/config", s.handleConfigRoute).Methods(http.MethodGet)
 
+	if s.jobs != nil {
+		sr.HandleFunc("/jobs/{sourceset}", s.handleJobCreate).Methods(http.MethodPost)
+		sr.HandleFunc("/jobs/{id}", s.handleJobGet).Methods(http.MethodGet)
+		sr.HandleFunc("/jobs/{id}/result", s.handleJobResult).Methods(http.MethodGet)
+	}
+
+	sr.HandleFunc("/md2html", s.handleMD2HTML).Methods(http.MethodPost)
+	sr.HandleFunc("/md2tex", s.handleMD2TeX).Methods(http.MethodPost)
+
 	sr.HandleFunc("/persist", s.handlePersistCreate).Methods(http.MethodPost)
 	sr.HandleFunc("/persist/{uid}", s.handlePersistGet).Methods(http.MethodGet)
 
+	sr.HandleFunc("/recipients/validate", s.handleRecipientsValidate).Methods(http.MethodPost)
+
 	sr.HandleFunc("/render/{sourceset}", s.handleRenderRoute).Methods(http.MethodPost)
 
 	sr.HandleFunc("/sets", s.handleSourceSetRoute).Methods(http.MethodGet)
+	sr.HandleFunc("/sets/{sourceset}/files/{file:.+}", s.handleSourceSetImage).Methods(http.MethodGet)
 }
 
 func (Server) respondJSON(w http.ResponseWriter, status int, err error, data any) {
@@ -77,11 +143,31 @@ func (Server) respondJSON(w http.ResponseWriter, status int, err error, data any
 
 	if err != nil {
 		logger.WithError(err).Error("handling http request")
-		data = map[string]any{
+		resp := map[string]any{
 			"success":   false,
 			"requestId": reqID,
 		}
 
+		var (
+			cErr latex.CompileError
+			tErr latex.TemplateError
+			vErr latex.ValidationError
+		)
+
+		switch {
+		case errors.As(err, &cErr):
+			resp["error"] = cErr.Error()
+			resp["compileErrors"] = cErr.Messages
+
+		case errors.As(err, &tErr):
+			resp["error"] = tErr.Error()
+
+		case errors.As(err, &vErr):
+			resp["errors"] = vErr.Problems
+		}
+
+		data = resp
+
 		if status == http.StatusOK {
 			status = http.StatusInternalServerError
 		}
@@ -93,6 +179,7 @@ func (Server) respondJSON(w http.ResponseWriter, status int, err error, data any
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err = json.NewEncoder(w).Encode(data); err != nil {
 		logger.WithError(err).Error("encoding response")
 	}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/Luzifer/doc-render/pkg/rendercache"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	cacheInputs struct {
+		Assets     map[string][]byte `json:"assets,omitempty"`
+		Recipients any               `json:"recipients"`
+		Sandbox    bool              `json:"sandbox,omitempty"`
+		Values     any               `json:"values"`
+	}
+)
+
+func (s Server) respondCachedPDF(w http.ResponseWriter, r *http.Request, opts latex.RenderOpts) {
+	key, err := s.cacheKey(opts)
+	if err != nil {
+		s.respondJSON(w, http.StatusInternalServerError, fmt.Errorf("generating cache key: %w", err), nil)
+		return
+	}
+
+	etag := fmt.Sprintf("%q", key)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if s.etagMatches(r.Header.Get("If-None-Match"), etag) {
+		// The render route only accepts POST requests for which RFC 9110
+		// requires the precondition to fail instead of a 304 response:
+		// the inputs are unchanged and the client already has the PDF
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	pdf, found, err := s.cache.Get(key)
+	if err != nil {
+		// A broken cache must not prevent rendering
+		logrus.WithError(err).Error("reading from render cache")
+	}
+
+	if !found {
+		w.Header().Set("X-Cache", "MISS")
+
+		if pdf, err = s.renderPDF(r, opts); err != nil {
+			w.Header().Del("ETag")
+			s.respondPDFError(w, err)
+			return
+		}
+
+		if err = s.cache.Set(key, pdf); err != nil {
+			logrus.WithError(err).Error("writing to render cache")
+		}
+	} else {
+		w.Header().Set("X-Cache", "HIT")
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+
+	if _, err = io.Copy(w, bytes.NewReader(pdf)); err != nil {
+		logrus.WithError(err).Error("copying PDF to remote browser")
+	}
+}
+
+func (Server) cacheKey(opts latex.RenderOpts) (string, error) {
+	sourceHash, err := latex.SourceSetHash(opts.SourceBaseFolder, opts.SourceSet)
+	if err != nil {
+		return "", fmt.Errorf("hashing source-set: %w", err)
+	}
+
+	key, err := rendercache.Key(sourceHash, cacheInputs{
+		Assets:     opts.Assets,
+		Recipients: opts.Recipients,
+		Sandbox:    opts.Sandbox,
+		Values:     opts.Values,
+	})
+	if err != nil {
+		return "", fmt.Errorf("hashing inputs: %w", err)
+	}
+
+	return key, nil
+}
+
+func (Server) etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (Server) renderPDF(r *http.Request, opts latex.RenderOpts) ([]byte, error) {
+	pdf, err := latex.Render(r.Context(), opts)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Error is wrapped when responding
+	}
+	defer func() {
+		if err := pdf.Close(); err != nil {
+			logrus.WithError(err).Error("closing PDF reader")
+		}
+	}()
+
+	content, err := io.ReadAll(pdf)
+	if err != nil {
+		return nil, fmt.Errorf("reading PDF: %w", err)
+	}
+
+	return content, nil
+}
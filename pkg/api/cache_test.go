@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/rendercache/mem"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticRenderer struct{}
+
+// Render returns a fixed document instead of a PDF
+func (staticRenderer) Render(context.Context, io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("%PDF")), nil
+}
+
+func TestRenderETag(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(base, "letter"), 0o700))
+	require.NoError(t, os.WriteFile(path.Join(base, "letter", "main.tex.tpl"), []byte(`{{ .Values.subject }}`), 0o600))
+	require.NoError(t, os.WriteFile(
+		path.Join(base, "letter", "schema.json"),
+		[]byte(`{"properties": {"subject": {"type": "string"}}}`),
+		0o600,
+	))
+
+	r := mux.NewRouter()
+	New(
+		WithCache(mem.New(1<<20, time.Hour)),
+		WithRenderer(staticRenderer{}),
+		WithSourceSetDir(base),
+	).Register(r)
+
+	render := func(body, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/render/letter", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := render(`{"values": {"subject": "a"}}`, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, "%PDF", w.Body.String())
+
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Unchanged input fails the precondition without a document
+	w = render(`{"values": {"subject": "a"}}`, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+
+	// Changed input is rendered having another ETag
+	w = render(`{"values": {"subject": "b"}}`, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	w = render(`{"values": {"subject": "a"}}`, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Luzifer/doc-render/pkg/jobs"
+	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func (s Server) handleJobCreate(w http.ResponseWriter, r *http.Request) {
+	opts, payload, ok := s.parseRenderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	serial := r.URL.Query().Get("mode") == "serial"
+
+	job, err := s.jobs.Submit(func(ctx context.Context, progress func(done, total int)) (jobs.Result, error) {
+		buf := new(bytes.Buffer)
+
+		if serial {
+			if err := latex.RenderSerial(ctx, buf, opts, latex.SerialOpts{
+				Concurrency:     s.renderConcurrency,
+				FileNamePattern: payload.FileNamePattern,
+				Progress:        progress,
+			}); err != nil {
+				return jobs.Result{}, fmt.Errorf("rendering serial letter: %w", err)
+			}
+
+			return jobs.Result{
+				Content:     buf.Bytes(),
+				ContentType: "application/zip",
+				FileName:    fmt.Sprintf("%s.zip", opts.SourceSet),
+			}, nil
+		}
+
+		progress(0, 1)
+
+		pdf, err := latex.Render(ctx, opts)
+		if err != nil {
+			return jobs.Result{}, fmt.Errorf("rendering PDF: %w", err)
+		}
+		defer func() {
+			if err := pdf.Close(); err != nil {
+				logrus.WithError(err).Error("closing PDF reader")
+			}
+		}()
+
+		if _, err = io.Copy(buf, pdf); err != nil {
+			return jobs.Result{}, fmt.Errorf("reading PDF: %w", err)
+		}
+
+		progress(1, 1)
+
+		return jobs.Result{
+			Content:     buf.Bytes(),
+			ContentType: "application/pdf",
+			FileName:    fmt.Sprintf("%s.pdf", opts.SourceSet),
+		}, nil
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrClosed) {
+			status = http.StatusServiceUnavailable
+		}
+
+		s.respondJSON(w, status, fmt.Errorf("submitting job: %w", err), nil)
+		return
+	}
+
+	s.respondJSON(w, http.StatusAccepted, nil, job)
+}
+
+func (s Server) handleJobGet(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Get(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondJSON(w, s.jobErrorStatus(err), fmt.Errorf("getting job: %w", err), nil)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, nil, job)
+}
+
+func (s Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	job, result, err := s.jobs.Result(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondJSON(w, s.jobErrorStatus(err), fmt.Errorf("getting job result: %w", err), nil)
+		return
+	}
+
+	if job.Status != jobs.StatusDone {
+		s.respondJSON(w, http.StatusConflict, fmt.Errorf("job is %s", job.Status), nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.FileName))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if _, err = io.Copy(w, bytes.NewReader(result)); err != nil {
+		logrus.WithError(err).Error("copying job result to remote browser")
+	}
+}
+
+func (Server) jobErrorStatus(err error) int {
+	if errors.Is(err, jobs.ErrNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
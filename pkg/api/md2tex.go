@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+)
+
+type (
+	md2texRequest struct {
+		// Args contains the additional arguments of the md2tex template
+		// function (HTML policy and / or quote language)
+		Args     []string          `json:"args,omitempty"`
+		Assets   map[string]string `json:"assets,omitempty"`
+		Markdown string            `json:"markdown"`
+		// SourceSet enables the images and shortcodes of the source-set
+		SourceSet string         `json:"sourceSet,omitempty"`
+		Values    map[string]any `json:"values,omitempty"`
+	}
+
+	md2htmlResponse struct {
+		HTML string `json:"html"`
+	}
+
+	md2texResponse struct {
+		TeX         string              `json:"tex"`
+		Diagnostics []md2tex.Diagnostic `json:"diagnostics"`
+	}
+)
+
+func (s Server) handleMD2HTML(w http.ResponseWriter, r *http.Request) {
+	payload, opts, ok := s.parseMarkdownRequest(w, r)
+	if !ok {
+		return
+	}
+
+	html, err := latex.PreviewHTML(opts, payload.Markdown, func(p string) string {
+		return (&url.URL{Path: fmt.Sprintf("/api/sets/%s/files/%s", payload.SourceSet, p)}).String()
+	}, payload.Args...)
+	if err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("converting markdown: %w", err), nil)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, nil, md2htmlResponse{HTML: html})
+}
+
+func (s Server) handleMD2TeX(w http.ResponseWriter, r *http.Request) {
+	payload, opts, ok := s.parseMarkdownRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tex, diags, err := latex.PreviewMarkdown(opts, payload.Markdown, payload.Args...)
+	if err != nil {
+		var (
+			tErr latex.TemplateError
+			vErr latex.ValidationError
+		)
+
+		status := http.StatusBadRequest
+		if errors.As(err, &tErr) || errors.As(err, &vErr) {
+			status = http.StatusUnprocessableEntity
+		}
+
+		s.respondJSON(w, status, fmt.Errorf("converting markdown: %w", err), nil)
+		return
+	}
+
+	if diags == nil {
+		diags = []md2tex.Diagnostic{}
+	}
+
+	s.respondJSON(w, http.StatusOK, nil, md2texResponse{TeX: tex, Diagnostics: diags})
+}
+
+// parseMarkdownRequest reads the request of the preview endpoints and
+// responds to the client in case of errors, in which case ok is false
+func (s Server) parseMarkdownRequest(w http.ResponseWriter, r *http.Request) (payload md2texRequest, opts latex.RenderOpts, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing request payload: %w", err), nil)
+		return payload, opts, false
+	}
+
+	if payload.SourceSet != "" && !s.isValidSourceSet(payload.SourceSet) {
+		s.respondJSON(w, http.StatusNotFound, fmt.Errorf("source-set %q not found", payload.SourceSet), nil)
+		return payload, opts, false
+	}
+
+	assets, err := decodeAssets(payload.Assets)
+	if err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("reading assets: %w", err), nil)
+		return payload, opts, false
+	}
+
+	return payload, latex.RenderOpts{
+		SourceBaseFolder: s.sourceSetDir,
+		SourceSet:        payload.SourceSet,
+
+		Assets:  assets,
+		Sandbox: s.sandbox,
+		Values:  payload.Values,
+	}, true
+}
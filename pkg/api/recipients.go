@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+)
+
+type (
+	validateRecipientsRequest struct {
+		FoxCSV           *string               `json:"foxCSV,omitempty"`
+		RecipientOptions *recipientcsv.Options `json:"recipientOptions,omitempty"`
+		Recipients       *recipientsPayload    `json:"recipients,omitempty"`
+		SourceSet        string                `json:"sourceSet,omitempty"`
+	}
+)
+
+func (s Server) handleRecipientsValidate(w http.ResponseWriter, r *http.Request) {
+	var payload validateRecipientsRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing request payload: %w", err), nil)
+		return
+	}
+
+	if payload.FoxCSV == nil && payload.Recipients == nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("no recipients given"), nil)
+		return
+	}
+
+	if payload.SourceSet != "" && !s.isValidSourceSet(payload.SourceSet) {
+		s.respondJSON(w, http.StatusNotFound, fmt.Errorf("source-set %q not found", payload.SourceSet), nil)
+		return
+	}
+
+	recipientOpts, err := s.recipientOptions(payload.SourceSet, payload.RecipientOptions)
+	if err != nil {
+		s.respondJSON(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	format, data, err := recipientData(payload.FoxCSV, payload.Recipients)
+	if err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("reading recipients: %w", err), nil)
+		return
+	}
+
+	report, err := recipientcsv.Validate(format, data, recipientOpts)
+	if err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing recipients: %w", err), nil)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, nil, report)
+}
+
+// recipientData returns the format (empty for auto-detection) and the
+// decoded data of the recipients given in the request
+func recipientData(foxCSV *string, recipients *recipientsPayload) (format string, data []byte, err error) {
+	if recipients == nil {
+		// Legacy field, only supports CSV
+		return recipientcsv.FormatCSV, []byte(*foxCSV), nil
+	}
+
+	switch recipients.Encoding {
+	case "":
+		return recipients.Format, []byte(recipients.Data), nil
+
+	case "base64":
+		if data, err = base64.StdEncoding.DecodeString(recipients.Data); err != nil {
+			return "", nil, fmt.Errorf("decoding base64 data: %w", err)
+		}
+		return recipients.Format, data, nil
+
+	default:
+		return "", nil, fmt.Errorf("unsupported encoding %q", recipients.Encoding)
+	}
+}
+
+// recipientOptions returns the recipient options of the source-set
+// (if given) having the request options applied on top
+func (s Server) recipientOptions(sourceSet string, override *recipientcsv.Options) (opts recipientcsv.Options, err error) {
+	if sourceSet != "" {
+		if opts, err = latex.GetRecipientOptions(s.sourceSetDir, sourceSet); err != nil {
+			return opts, fmt.Errorf("getting recipient options: %w", err)
+		}
+	}
+
+	if override != nil {
+		opts = opts.Merge(*override)
+	}
+
+	return opts, nil
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,43 +16,174 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+type (
+	// countingWriter keeps track whether data has already been sent
+	// to the client
+	countingWriter struct {
+		w io.Writer
+		n int64
+	}
+
+	dryRunResponse struct {
+		Values any `json:"values"`
+	}
+)
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err //nolint:wrapcheck // Transparent wrapper
+}
+
 func (s Server) handleRenderRoute(w http.ResponseWriter, r *http.Request) {
+	opts, payload, ok := s.parseRenderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	switch output := r.URL.Query().Get("output"); output {
+	case "", "pdf":
+		if r.URL.Query().Get("mode") == "serial" {
+			s.respondSerial(w, r, opts, payload.FileNamePattern)
+			return
+		}
+
+		s.respondPDF(w, r, opts)
+
+	case "zip":
+		s.respondSource(w, opts, latex.Pack, "application/zip", fmt.Sprintf("%s.zip", opts.SourceSet))
+
+	case "tex":
+		s.respondSource(w, opts, latex.RenderTeX, "text/x-tex", "main.tex")
+
+	case "values":
+		s.respondJSON(w, http.StatusOK, nil, dryRunResponse{Values: opts.Values})
+
+	default:
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("invalid output %q", output), nil)
+	}
+}
+
+// parseRenderRequest reads and validates the render request for the
+// source-set given in the route and responds to the client in case
+// of errors, in which case ok is false
+func (s Server) parseRenderRequest(w http.ResponseWriter, r *http.Request) (opts latex.RenderOpts, payload renderRequest, ok bool) {
 	var (
 		addrTo    = []recipientcsv.Person{{}}
 		err       error
-		payload   renderRequest
 		sourceSet = mux.Vars(r)["sourceset"]
 	)
 
 	if ct, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); ct != "application/json" {
 		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("invalid payload type %q", ct), nil)
-		return
+		return opts, payload, false
 	}
 
 	if err = json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing request payload: %w", err), nil)
-		return
+		return opts, payload, false
 	}
 
-	if payload.FoxCSV != nil {
-		if addrTo, err = recipientcsv.Parse(strings.NewReader(*payload.FoxCSV)); err != nil {
-			s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing FoxCSV: %w", err), nil)
-			return
+	if !latex.HasSourceSet(s.sourceSetDir, sourceSet) {
+		s.respondJSON(w, http.StatusNotFound, fmt.Errorf("source-set %q not found", sourceSet), nil)
+		return opts, payload, false
+	}
+
+	schema, err := latex.GetSourceSet(s.sourceSetDir, sourceSet)
+	if err != nil {
+		s.respondJSON(w, http.StatusInternalServerError, fmt.Errorf("getting source-set definition: %w", err), nil)
+		return opts, payload, false
+	}
+
+	values := latex.ApplyDefaults(schema, payload.Values)
+	if err = latex.ValidateValues(schema, values); err != nil {
+		s.respondJSON(w, http.StatusUnprocessableEntity, fmt.Errorf("validating values: %w", err), nil)
+		return opts, payload, false
+	}
+
+	if payload.FoxCSV != nil || payload.Recipients != nil {
+		if addrTo, err = s.parseRecipients(sourceSet, payload); err != nil {
+			s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("parsing recipients: %w", err), nil)
+			return opts, payload, false
 		}
 	}
 
-	// Generate document
-	pdf, err := latex.Render(r.Context(), latex.RenderOpts{
-		TexAPIURL: s.texAPIJobURL,
+	assets, err := decodeAssets(payload.Assets)
+	if err != nil {
+		s.respondJSON(w, http.StatusBadRequest, fmt.Errorf("reading assets: %w", err), nil)
+		return opts, payload, false
+	}
+
+	opts = latex.RenderOpts{
+		Renderer: s.renderer,
 
 		SourceBaseFolder: s.sourceSetDir,
 		SourceSet:        sourceSet,
 
+		Assets:     assets,
 		Recipients: addrTo,
-		Values:     payload.Values,
-	})
+		Sandbox:    s.sandbox,
+		Values:     values,
+	}
+
+	if err = latex.CheckSandbox(opts); err != nil {
+		s.respondJSON(w, http.StatusUnprocessableEntity, fmt.Errorf("checking sandbox: %w", err), nil)
+		return opts, payload, false
+	}
+
+	return opts, payload, true
+}
+
+// decodeAssets decodes the base64 encoded assets of the request and
+// checks their names
+func decodeAssets(encoded map[string]string) (map[string][]byte, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	assets := make(map[string][]byte, len(encoded))
+	for name, data := range encoded {
+		content, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding asset %q: %w", name, err)
+		}
+		assets[name] = content
+	}
+
+	if err := latex.ValidateAssets(assets); err != nil {
+		return nil, fmt.Errorf("validating assets: %w", err)
+	}
+
+	return assets, nil
+}
+
+// parseRecipients reads the recipients from the payload using the
+// recipient options of the source-set with those of the payload
+// applied on top
+func (s Server) parseRecipients(sourceSet string, payload renderRequest) ([]recipientcsv.Person, error) {
+	recipientOpts, err := s.recipientOptions(sourceSet, payload.RecipientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	format, data, err := recipientData(payload.FoxCSV, payload.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipientcsv.ParseFormat(format, data, recipientOpts) //nolint:wrapcheck // Wrapped by caller
+}
+
+func (s Server) respondPDF(w http.ResponseWriter, r *http.Request, opts latex.RenderOpts) {
+	if s.cache != nil {
+		s.respondCachedPDF(w, r, opts)
+		return
+	}
+
+	// Generate document
+	pdf, err := latex.Render(r.Context(), opts)
 	if err != nil {
-		s.respondJSON(w, http.StatusInternalServerError, fmt.Errorf("rendering PDF: %w", err), nil)
+		s.respondPDFError(w, err)
 		return
 	}
 	defer func() {
@@ -65,3 +199,68 @@ func (s Server) handleRenderRoute(w http.ResponseWriter, r *http.Request) {
 		logrus.WithError(err).Error("copying PDF to remote browser")
 	}
 }
+
+func (s Server) respondSerial(w http.ResponseWriter, r *http.Request, opts latex.RenderOpts, fileNamePattern string) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s.zip", opts.SourceSet)))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	cw := &countingWriter{w: w}
+	err := latex.RenderSerial(r.Context(), cw, opts, latex.SerialOpts{
+		Concurrency:     s.renderConcurrency,
+		FileNamePattern: fileNamePattern,
+	})
+
+	switch {
+	case err == nil:
+		// Archive was streamed successfully
+
+	case cw.n == 0:
+		// Nothing was sent yet, we still can report the error properly
+		w.Header().Del("Content-Disposition")
+		s.respondPDFError(w, err)
+
+	default:
+		// The archive is already partially sent, the only thing we can
+		// do is to abort the transfer
+		logrus.WithError(err).Error("rendering serial letter")
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (s Server) respondSource(
+	w http.ResponseWriter,
+	opts latex.RenderOpts,
+	gen func(io.Writer, latex.RenderOpts) error,
+	contentType, fileName string,
+) {
+	// Buffer the output in order to be able to report template errors
+	buf := new(bytes.Buffer)
+	if err := gen(buf, opts); err != nil {
+		s.respondJSON(w, http.StatusUnprocessableEntity, fmt.Errorf("generating source: %w", err), nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if _, err := io.Copy(w, buf); err != nil {
+		logrus.WithError(err).Error("copying source to remote browser")
+	}
+}
+
+func (s Server) respondPDFError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	var (
+		cErr latex.CompileError
+		tErr latex.TemplateError
+		vErr latex.ValidationError
+	)
+	if errors.As(err, &cErr) || errors.As(err, &tErr) || errors.As(err, &vErr) {
+		status = http.StatusUnprocessableEntity
+	}
+
+	s.respondJSON(w, status, fmt.Errorf("rendering PDF: %w", err), nil)
+}
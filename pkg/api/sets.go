@@ -2,9 +2,14 @@ package api
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
+	"path"
+	"strings"
 
 	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 )
 
 func (s Server) handleSourceSetRoute(w http.ResponseWriter, _ *http.Request) {
@@ -16,3 +21,42 @@ func (s Server) handleSourceSetRoute(w http.ResponseWriter, _ *http.Request) {
 
 	s.respondJSON(w, http.StatusOK, nil, sets)
 }
+
+func (s Server) handleSourceSetImage(w http.ResponseWriter, r *http.Request) {
+	var (
+		sourceSet = mux.Vars(r)["sourceset"]
+		file      = mux.Vars(r)["file"]
+	)
+
+	if !latex.HasSourceSet(s.sourceSetDir, sourceSet) {
+		s.respondJSON(w, http.StatusNotFound, fmt.Errorf("source-set %q not found", sourceSet), nil)
+		return
+	}
+
+	content, err := latex.ReadPreviewImage(s.sourceSetDir, sourceSet, file)
+	if err != nil {
+		s.respondJSON(w, http.StatusNotFound, fmt.Errorf("reading image: %w", err), nil)
+		return
+	}
+
+	if mimeType := mime.TypeByExtension(path.Ext(file)); mimeType != "" {
+		w.Header().Set("Content-Type", mimeType)
+	}
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err = w.Write(content); err != nil {
+		logrus.WithError(err).Error("writing image")
+	}
+}
+
+// isValidSourceSet checks a source-set name taken from a request body
+// (not sanitized by the router) to be a plain directory name before
+// checking it to exist
+func (s Server) isValidSourceSet(name string) bool {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return false
+	}
+
+	return latex.HasSourceSet(s.sourceSetDir, name)
+}
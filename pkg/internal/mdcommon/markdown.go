@@ -0,0 +1,72 @@
+package mdcommon
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+type (
+	// HTMLTag describes an inline HTML tag
+	HTMLTag struct {
+		// Name of the tag in lower case
+		Name        string
+		Closing     bool
+		SelfClosing bool
+	}
+)
+
+var (
+	htmlComment = regexp.MustCompile(`^<!--[\s\S]*-->$`)
+	htmlTag     = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9]*)(?:\s[^>]*?)?\s*(/?)>$`)
+)
+
+// ImageWidth splits the width given in the fragment of the image
+// destination (i.e. `image.png#width=50%`) from the path
+func ImageWidth(dest string) (path, width string) {
+	path, fragment, _ := strings.Cut(dest, "#")
+
+	for _, opt := range strings.Split(fragment, "&") {
+		key, value, _ := strings.Cut(opt, "=")
+		if key == "width" && value != "" {
+			width = value
+		}
+	}
+
+	return path, width
+}
+
+// IsHTMLComment checks whether the raw HTML is a comment
+func IsHTMLComment(raw string) bool {
+	return htmlComment.MatchString(strings.TrimSpace(raw))
+}
+
+// ParseHTMLTag parses a single HTML tag, attributes are dropped
+func ParseHTMLTag(raw string) (HTMLTag, bool) {
+	m := htmlTag.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return HTMLTag{}, false
+	}
+
+	return HTMLTag{
+		Name:        strings.ToLower(m[2]),
+		Closing:     m[1] == "/",
+		SelfClosing: m[3] == "/",
+	}, true
+}
+
+// PlainText collects the text of all children of the node
+func PlainText(node ast.Node, source []byte) []byte {
+	buf := new(bytes.Buffer)
+
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := n.(*ast.Text); ok && entering {
+			buf.Write(t.Segment.Value(source))
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return buf.Bytes()
+}
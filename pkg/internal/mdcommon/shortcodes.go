@@ -0,0 +1,182 @@
+// Package mdcommon contains the parts of the Markdown conversion shared
+// by md2tex and md2html in order for the preview to match the output
+package mdcommon
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Placeholders for the shortcodes in the document while it is parsed
+// by goldmark: as the parser splits the text at characters having a
+// meaning in Markdown the shortcodes are replaced before parsing.
+const (
+	placeholderStart = '\uE000'
+	placeholderEnd   = '\uE001'
+)
+
+type (
+	// Shortcode is a shortcode call found in the document
+	Shortcode struct {
+		// Call contains the shortcode including the delimiters
+		Call string
+		// Offset of the call in the original document
+		Offset int
+		// PlaceholderEnd is the offset after the placeholder in the
+		// document passed to the parser
+		PlaceholderEnd int
+	}
+
+	// Shortcodes contains the shortcode calls of a document in order
+	Shortcodes []Shortcode
+)
+
+var (
+	// SpaceDimension matches the dimensions accepted by `vspace`
+	SpaceDimension = regexp.MustCompile(`^-?\d*\.?\d+(?:cm|mm|in|pt|em|ex)$`)
+
+	shortcodeArg         = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`|\\S+")
+	shortcodeDef         = regexp.MustCompile(`{%\s*(.*?)\s*%}`)
+	shortcodePlaceholder = regexp.MustCompile(string(placeholderStart) + `(\d+)` + string(placeholderEnd))
+)
+
+// ExtractShortcodes replaces all shortcodes in the document by
+// placeholders and returns the shortcode calls in order
+func ExtractShortcodes(md []byte) ([]byte, Shortcodes) {
+	var (
+		calls Shortcodes
+		out   = new(bytes.Buffer)
+		last  = 0
+	)
+
+	// Placeholder characters in the document itself must not be taken
+	// for shortcodes, being private-use characters they are replaced
+	// keeping the offsets of the document intact
+	md = bytes.Map(func(r rune) rune {
+		if r == placeholderStart || r == placeholderEnd {
+			return utf8.RuneError
+		}
+		return r
+	}, md)
+
+	for _, loc := range shortcodeDef.FindAllIndex(md, -1) {
+		out.Write(md[last:loc[0]])
+		fmt.Fprintf(out, "%c%d%c", placeholderStart, len(calls), placeholderEnd)
+
+		calls = append(calls, Shortcode{
+			Call:           string(md[loc[0]:loc[1]]),
+			Offset:         loc[0],
+			PlaceholderEnd: out.Len(),
+		})
+
+		last = loc[1]
+	}
+	out.Write(md[last:])
+
+	return out.Bytes(), calls
+}
+
+// ResolveArg unquotes the argument (double quotes or backticks) or
+// looks up the referenced value (`.Values.a.b`), bare words are
+// returned unchanged
+func ResolveArg(arg string, values any) (string, error) {
+	switch {
+	case strings.HasPrefix(arg, `"`):
+		s, err := strconv.Unquote(arg)
+		if err != nil {
+			return "", fmt.Errorf("invalid argument %s: %w", arg, err)
+		}
+		return s, nil
+
+	case strings.HasPrefix(arg, "`"):
+		if len(arg) < 2 || !strings.HasSuffix(arg, "`") {
+			return "", fmt.Errorf("invalid argument %s", arg)
+		}
+		return arg[1 : len(arg)-1], nil
+
+	case strings.HasPrefix(arg, ".Values."):
+		v := values
+		for _, key := range strings.Split(strings.TrimPrefix(arg, ".Values."), ".") {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("value %s not found", arg)
+			}
+			v = m[key]
+		}
+
+		if v == nil {
+			return "", fmt.Errorf("value %s not found", arg)
+		}
+		return fmt.Sprint(v), nil
+
+	default:
+		return arg, nil
+	}
+}
+
+// ShortcodeContent returns the content of the call without the `{%`
+// and `%}` delimiters
+func ShortcodeContent(call string) string {
+	m := shortcodeDef.FindStringSubmatch(call)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// SplitArgs splits the content of a shortcode call into the name and
+// the (still quoted) arguments
+func SplitArgs(content string) (name string, args []string) {
+	fields := shortcodeArg.FindAllString(content, -1)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	return fields[0], fields[1:]
+}
+
+// Render passes the text between the placeholders contained in the
+// value to text and the shortcodes to shortcode in order
+func (s Shortcodes) Render(value []byte, text func([]byte), shortcode func(Shortcode)) {
+	last := 0
+
+	for _, loc := range shortcodePlaceholder.FindAllSubmatchIndex(value, -1) {
+		sc, ok := s.lookup(value[loc[2]:loc[3]])
+		if !ok {
+			continue
+		}
+
+		text(value[last:loc[0]])
+		shortcode(sc)
+
+		last = loc[1]
+	}
+
+	text(value[last:])
+}
+
+// Restore replaces the shortcode placeholders by the original shortcode
+// calls passed through the given function for contexts not rendering
+// shortcodes (i.e. code)
+func (s Shortcodes) Restore(value []byte, fn func(call string) string) []byte {
+	return shortcodePlaceholder.ReplaceAllFunc(value, func(match []byte) []byte {
+		if sc, ok := s.lookup(shortcodePlaceholder.FindSubmatch(match)[1]); ok {
+			return []byte(fn(sc.Call))
+		}
+		return match
+	})
+}
+
+// lookup returns the shortcode call for the placeholder index
+func (s Shortcodes) lookup(idx []byte) (Shortcode, bool) {
+	i, err := strconv.Atoi(string(idx))
+	if err != nil || i >= len(s) {
+		return Shortcode{}, false
+	}
+
+	return s[i], true
+}
@@ -0,0 +1,46 @@
+package mdcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractShortcodes(t *testing.T) {
+	// Placeholder characters in the document must not be taken for
+	// shortcodes
+	md, calls := ExtractShortcodes([]byte("a {% vspace 1cm %} b \uE0000\uE001 `{% raw x %}`"))
+	require.Len(t, calls, 2)
+	assert.Equal(t, Shortcode{Call: "{% vspace 1cm %}", Offset: 2, PlaceholderEnd: 9}, calls[0])
+
+	var parts []string
+	calls.Render(md, func(text []byte) { parts = append(parts, string(text)) }, func(sc Shortcode) {
+		parts = append(parts, "<"+ShortcodeContent(sc.Call)+">")
+	})
+	assert.Equal(t, []string{"a ", "<vspace 1cm>", " b \uFFFD0\uFFFD `", "<raw x>", "`"}, parts)
+
+	restored := calls.Restore(md, func(call string) string { return call })
+	assert.Equal(t, "a {% vspace 1cm %} b \uFFFD0\uFFFD `{% raw x %}`", string(restored))
+}
+
+func TestShortcodeArgs(t *testing.T) {
+	name, args := SplitArgs("graphic \"my logo.png\" `3cm` .Values.width bare")
+	assert.Equal(t, "graphic", name)
+	assert.Equal(t, []string{`"my logo.png"`, "`3cm`", ".Values.width", "bare"}, args)
+
+	values := map[string]any{"width": "2cm"}
+	for arg, expect := range map[string]string{
+		`"my logo.png"`: "my logo.png",
+		"`3cm`":         "3cm",
+		".Values.width": "2cm",
+		"bare":          "bare",
+	} {
+		v, err := ResolveArg(arg, values)
+		require.NoError(t, err)
+		assert.Equal(t, expect, v)
+	}
+
+	_, err := ResolveArg(".Values.missing", values)
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+// Package jobs implements asynchronous rendering jobs executed by a
+// bounded pool of workers and defines an interface to store the jobs
+// and their results
+package jobs
+
+import (
+	"errors"
+	"time"
+)
+
+// Status values of a job
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusFailed  Status = "failed"
+	StatusDone    Status = "done"
+)
+
+type (
+	// Job represents the state of an asynchronous job
+	Job struct {
+		ID       string   `json:"id"`
+		Status   Status   `json:"status"`
+		Progress Progress `json:"progress"`
+		Error    string   `json:"error,omitempty"`
+
+		ContentType string `json:"contentType,omitempty"`
+		FileName    string `json:"fileName,omitempty"`
+
+		CreatedAt time.Time `json:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	// Progress describes how many of the documents of a job are done
+	Progress struct {
+		Done  int `json:"done"`
+		Total int `json:"total"`
+	}
+
+	// Status describes the state of a job
+	Status string
+
+	// Store defines the interface to implement when implementing a
+	// storage for jobs and their results
+	Store interface {
+		// Delete removes the job and its result
+		Delete(id string) error
+		// Get retrieves the job by its ID and returns ErrNotFound if
+		// the job does not exist
+		Get(id string) (Job, error)
+		// GetResult retrieves the result of the job by its ID and
+		// returns ErrNotFound if no result is available
+		GetResult(id string) ([]byte, error)
+		// Save creates or updates the job
+		Save(job Job) error
+		// SaveResult stores the result of the job
+		SaveResult(id string, result []byte) error
+	}
+)
+
+// ErrNotFound is returned by the Store if the job does not exist
+var ErrNotFound = errors.New("job not found")
+
+// Finished returns whether the job will not change anymore
+func (j Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
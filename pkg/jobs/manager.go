@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// Manager executes the jobs using a bounded pool of workers
+	Manager struct {
+		ctx     context.Context
+		cancel  context.CancelFunc
+		queue   chan queuedJob
+		store   Store
+		timeout time.Duration
+	}
+
+	// Result contains the outcome of a successful job
+	Result struct {
+		Content     []byte
+		ContentType string
+		FileName    string
+	}
+
+	// WorkFunc executes the job and reports its progress through the
+	// given function
+	WorkFunc func(ctx context.Context, progress func(done, total int)) (Result, error)
+
+	queuedJob struct {
+		id string
+		fn WorkFunc
+	}
+)
+
+var (
+	// ErrClosed is returned when submitting a job after the Manager
+	// has been closed
+	ErrClosed = errors.New("job manager is closed")
+	// ErrQueueFull is returned when submitting a job while the queue
+	// does not accept any more jobs
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// NewManager creates a new Manager starting the given number of
+// workers and accepting up to queueSize jobs waiting for a worker.
+// Each job is cancelled after the timeout (if greater than zero) or
+// when the Manager is closed.
+func NewManager(store Store, workers, queueSize int, timeout time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		queue:   make(chan queuedJob, queueSize),
+		store:   store,
+		timeout: timeout,
+	}
+
+	for range max(workers, 1) {
+		go m.worker()
+	}
+
+	return m
+}
+
+// Close cancels the running jobs, queued jobs fail when picked up
+// by a worker and new jobs are rejected with ErrClosed
+func (m *Manager) Close() {
+	m.cancel()
+}
+
+// Get retrieves the job by its ID
+func (m *Manager) Get(id string) (Job, error) {
+	return m.store.Get(id) //nolint:wrapcheck // Transparent wrapper
+}
+
+// Result retrieves the job and its result by the job ID
+func (m *Manager) Result(id string) (Job, []byte, error) {
+	job, err := m.store.Get(id)
+	if err != nil {
+		return Job{}, nil, fmt.Errorf("getting job: %w", err)
+	}
+
+	if job.Status != StatusDone {
+		return job, nil, nil
+	}
+
+	result, err := m.store.GetResult(id)
+	if err != nil {
+		return job, nil, fmt.Errorf("getting result: %w", err)
+	}
+
+	return job, result, nil
+}
+
+// Submit creates a new job and enqueues it for execution
+func (m *Manager) Submit(fn WorkFunc) (Job, error) {
+	if m.ctx.Err() != nil {
+		return Job{}, ErrClosed
+	}
+
+	now := time.Now()
+	job := Job{
+		ID:        uuid.New().String(),
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := m.store.Save(job); err != nil {
+		return Job{}, fmt.Errorf("saving job: %w", err)
+	}
+
+	select {
+	case m.queue <- queuedJob{id: job.ID, fn: fn}:
+		return job, nil
+
+	default:
+		// The caller never gets to know the ID of the job
+		if err := m.store.Delete(job.ID); err != nil {
+			logrus.WithError(err).WithField("job_id", job.ID).Error("deleting rejected job")
+		}
+		return Job{}, ErrQueueFull
+	}
+}
+
+func (m *Manager) execute(qj queuedJob) {
+	logger := logrus.WithField("job_id", qj.id)
+
+	m.update(qj.id, func(j *Job) { j.Status = StatusRunning })
+
+	ctx, cancel := m.ctx, context.CancelFunc(func() {})
+	if m.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+	}
+	defer cancel()
+
+	result, err := qj.fn(ctx, func(done, total int) {
+		m.update(qj.id, func(j *Job) { j.Progress = Progress{Done: done, Total: total} })
+	})
+	if err != nil {
+		logger.WithError(err).Error("executing job")
+		m.update(qj.id, func(j *Job) {
+			j.Status = StatusFailed
+			j.Error = err.Error()
+		})
+		return
+	}
+
+	if err = m.store.SaveResult(qj.id, result.Content); err != nil {
+		logger.WithError(err).Error("saving job result")
+		m.update(qj.id, func(j *Job) {
+			j.Status = StatusFailed
+			j.Error = "saving result failed"
+		})
+		return
+	}
+
+	m.update(qj.id, func(j *Job) {
+		j.Status = StatusDone
+		j.ContentType = result.ContentType
+		j.FileName = result.FileName
+	})
+}
+
+func (m *Manager) update(id string, fn func(*Job)) {
+	job, err := m.store.Get(id)
+	if err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("getting job for update")
+		return
+	}
+
+	fn(&job)
+	job.UpdatedAt = time.Now()
+
+	if err = m.store.Save(job); err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("saving job")
+	}
+}
+
+func (m *Manager) worker() {
+	for qj := range m.queue {
+		m.execute(qj)
+	}
+}
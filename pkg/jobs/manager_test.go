@@ -0,0 +1,133 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/jobs"
+	"github.com/Luzifer/doc-render/pkg/jobs/mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStore keeps track of the last job saved
+type recordingStore struct {
+	*mem.Store
+	lastID string
+	lock   sync.Mutex
+}
+
+func (r *recordingStore) Save(job jobs.Job) error {
+	r.lock.Lock()
+	r.lastID = job.ID
+	r.lock.Unlock()
+
+	return r.Store.Save(job) //nolint:wrapcheck // Transparent wrapper
+}
+
+func TestManager(t *testing.T) {
+	m := jobs.NewManager(mem.New(time.Hour), 1, 10, 0)
+
+	job, err := m.Submit(func(_ context.Context, progress func(done, total int)) (jobs.Result, error) {
+		progress(1, 1)
+		return jobs.Result{Content: []byte("pdf"), ContentType: "application/pdf", FileName: "doc.pdf"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusQueued, job.Status)
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, jobs.StatusDone, job.Status)
+	assert.Equal(t, jobs.Progress{Done: 1, Total: 1}, job.Progress)
+
+	job, result, err := m.Result(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "doc.pdf", job.FileName)
+	assert.Equal(t, []byte("pdf"), result)
+
+	job, err = m.Submit(func(context.Context, func(int, int)) (jobs.Result, error) {
+		return jobs.Result{}, errors.New("broken")
+	})
+	require.NoError(t, err)
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+	assert.Equal(t, "broken", job.Error)
+
+	_, err = m.Get("unknown")
+	assert.ErrorIs(t, err, jobs.ErrNotFound)
+}
+
+func TestManagerCancel(t *testing.T) {
+	m := jobs.NewManager(mem.New(time.Hour), 2, 10, 50*time.Millisecond)
+
+	job, err := m.Submit(blockingJob)
+	require.NoError(t, err)
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), job.Error)
+
+	m = jobs.NewManager(mem.New(time.Hour), 1, 10, time.Hour)
+
+	job, err = m.Submit(blockingJob)
+	require.NoError(t, err)
+
+	m.Close()
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+	assert.Equal(t, context.Canceled.Error(), job.Error)
+
+	_, err = m.Submit(blockingJob)
+	assert.ErrorIs(t, err, jobs.ErrClosed)
+}
+
+func TestManagerQueueFull(t *testing.T) {
+	store := &recordingStore{Store: mem.New(time.Hour)}
+	m := jobs.NewManager(store, 1, 1, time.Hour)
+	defer m.Close()
+
+	running, err := m.Submit(blockingJob)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		job, err := m.Get(running.ID)
+		require.NoError(t, err)
+		return job.Status == jobs.StatusRunning
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = m.Submit(blockingJob)
+	require.NoError(t, err)
+
+	_, err = m.Submit(blockingJob)
+	assert.ErrorIs(t, err, jobs.ErrQueueFull)
+
+	// The rejected job must not be kept in the store
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	_, err = m.Get(store.lastID)
+	assert.ErrorIs(t, err, jobs.ErrNotFound)
+}
+
+func blockingJob(ctx context.Context, _ func(int, int)) (jobs.Result, error) {
+	<-ctx.Done()
+	return jobs.Result{}, ctx.Err()
+}
+
+func waitFinished(t *testing.T, m *jobs.Manager, id string) jobs.Job {
+	t.Helper()
+
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		require.NoError(t, err)
+		return job.Finished()
+	}, time.Second, 10*time.Millisecond)
+
+	return job
+}
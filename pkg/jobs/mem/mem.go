@@ -0,0 +1,113 @@
+// Package mem implements a storage backend to hold the jobs and
+// their results inside memory
+package mem
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/jobs"
+)
+
+const cleanupInterval = time.Minute
+
+type (
+	// Store implements the jobs.Store interface for Memory storage
+	Store struct {
+		jobs      map[string]jobs.Job
+		results   map[string][]byte
+		retention time.Duration
+
+		lock sync.RWMutex
+	}
+)
+
+var _ jobs.Store = (*Store)(nil)
+
+// New creates a new memory job store removing finished jobs and their
+// results after the given retention
+func New(retention time.Duration) *Store {
+	s := &Store{
+		jobs:      map[string]jobs.Job{},
+		results:   map[string][]byte{},
+		retention: retention,
+	}
+
+	go s.cleanupLoop()
+
+	return s
+}
+
+// Delete removes the job and its result
+func (s *Store) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.jobs, id)
+	delete(s.results, id)
+	return nil
+}
+
+// Get retrieves the job by its ID and returns ErrNotFound if
+// the job does not exist
+func (s *Store) Get(id string) (jobs.Job, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return jobs.Job{}, jobs.ErrNotFound
+	}
+
+	return job, nil
+}
+
+// GetResult retrieves the result of the job by its ID and
+// returns ErrNotFound if no result is available
+func (s *Store) GetResult(id string) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	result, ok := s.results[id]
+	if !ok {
+		return nil, jobs.ErrNotFound
+	}
+
+	return result, nil
+}
+
+// Save creates or updates the job
+func (s *Store) Save(job jobs.Job) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// SaveResult stores the result of the job
+func (s *Store) SaveResult(id string, result []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.results[id] = result
+	return nil
+}
+
+func (s *Store) cleanup() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for id, job := range s.jobs {
+		if job.Finished() && time.Since(job.UpdatedAt) > s.retention {
+			delete(s.jobs, id)
+			delete(s.results, id)
+		}
+	}
+}
+
+func (s *Store) cleanupLoop() {
+	for range time.NewTicker(cleanupInterval).C {
+		s.cleanup()
+	}
+}
@@ -0,0 +1,239 @@
+package latex
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	asciidocDelimiter  = regexp.MustCompile(`^(?:-{4,}|\.{4,})$`)
+	asciidocHeading    = regexp.MustCompile(`^(={1,6})\s+(.+)$`)
+	asciidocImageBlock = regexp.MustCompile(`^image::([^\s\[]+)\[([^\]]*)\]$`)
+	asciidocList       = regexp.MustCompile(`^(\*{1,5}|-|\.{1,5})\s+(.*)$`)
+	asciidocSource     = regexp.MustCompile(`^\[source(?:,\s*([A-Za-z0-9+#-]+))?.*\]$`)
+
+	// asciidocInline matches (in this order) monospace, inline images,
+	// links with label, bare URLs, bold, italic and shortcodes
+	asciidocInline = regexp.MustCompile("`([^`]+)`" +
+		`|image:([^\s\[]+)\[([^\]]*)\]` +
+		`|(?:link:)?(https?://[^\s\[]+|mailto:[^\s\[]+)\[([^\]]*)\]` +
+		`|(https?://[^\s\[<>]*[^\s\[<>.,;:!?)])` +
+		`|\*([^*\s](?:[^*]*[^*\s])?)\*` +
+		`|_([^_\s](?:[^_]*[^_\s])?)_` +
+		`|\{%.*?%\}`)
+
+	// markdownSpecials are escaped in text in order not to be taken as
+	// Markdown syntax
+	markdownSpecials = "\\`*_[]#<>!~|&"
+)
+
+// asciidocToMarkdown translates the supported subset of AsciiDoc
+// (section titles, paragraphs with hard line breaks, nested lists,
+// listing blocks, images, links, bold, italic and monospace text) into
+// Markdown to be converted by md2tex
+func asciidocToMarkdown(src string) string {
+	var (
+		out        []string
+		inListing  bool
+		language   string
+		listWidths []int
+	)
+
+	for _, line := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src), "\n") {
+		trimmed := strings.TrimRight(line, " \t")
+
+		if inListing {
+			if asciidocDelimiter.MatchString(trimmed) {
+				out = append(out, "```")
+				inListing = false
+				continue
+			}
+
+			out = append(out, line)
+			continue
+		}
+
+		if trimmed == "" {
+			listWidths = nil
+			out = append(out, "")
+			continue
+		}
+
+		if m := asciidocList.FindStringSubmatch(trimmed); m != nil {
+			var (
+				level  = len(m[1])
+				marker = "- "
+			)
+
+			if m[1][0] == '.' {
+				marker = "1. "
+			}
+
+			if level > len(listWidths)+1 {
+				// Levels must not be skipped in Markdown
+				level = len(listWidths) + 1
+			}
+
+			listWidths = append(listWidths[:level-1], len(marker))
+
+			indent := 0
+			for _, w := range listWidths[:level-1] {
+				indent += w
+			}
+
+			out = append(out, strings.Repeat(" ", indent)+marker+asciidocParagraphLine(m[2]))
+			continue
+		}
+
+		switch m := asciidocSource.FindStringSubmatch(trimmed); {
+		case m != nil:
+			language = m[1]
+			continue
+
+		case asciidocDelimiter.MatchString(trimmed):
+			out = append(out, "```"+language)
+			inListing, language = true, ""
+			continue
+		}
+
+		listWidths = nil
+
+		switch {
+		case strings.HasPrefix(trimmed, "//"):
+			// Comment
+
+		case trimmed == "'''":
+			out = append(out, "", "---", "")
+
+		case asciidocHeading.MatchString(trimmed):
+			m := asciidocHeading.FindStringSubmatch(trimmed)
+			out = append(out, "", strings.Repeat("#", len(m[1]))+" "+asciidocInlineToMarkdown(m[2]), "")
+
+		case asciidocImageBlock.MatchString(trimmed):
+			m := asciidocImageBlock.FindStringSubmatch(trimmed)
+			out = append(out, "", "!["+escapeMarkdown(m[2])+"]("+m[1]+")", "")
+
+		default:
+			out = append(out, asciidocParagraphLine(trimmed))
+		}
+	}
+
+	if inListing {
+		out = append(out, "```")
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// asciidocParagraphLine converts a line of text including the hard
+// line break (` +` at the end of the line)
+func asciidocParagraphLine(line string) string {
+	hardBreak := strings.HasSuffix(line, " +")
+	if hardBreak {
+		line = strings.TrimSuffix(line, " +")
+	}
+
+	md := asciidocInlineToMarkdown(line)
+
+	// Characters at the start of the line which would start a block
+	if len(md) > 0 && strings.ContainsRune("-+=", rune(md[0])) {
+		md = `\` + md
+	}
+
+	if i := strings.IndexFunc(md, func(r rune) bool { return !unicode.IsDigit(r) }); i > 0 && strings.ContainsRune(".)", rune(md[i])) {
+		md = md[:i] + `\` + md[i:]
+	}
+
+	if hardBreak {
+		md += `\`
+	}
+
+	return md
+}
+
+// asciidocInlineToMarkdown converts the inline formatting of the text
+func asciidocInlineToMarkdown(text string) string {
+	var (
+		buf  strings.Builder
+		last = 0
+	)
+
+	for _, m := range asciidocInline.FindAllStringSubmatchIndex(text, -1) {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return text[m[2*i]:m[2*i+1]]
+		}
+
+		if (m[14] >= 0 || m[16] >= 0) && !isConstrained(text, m[0], m[1]) {
+			// Bold / italic markers within words are kept as text
+			continue
+		}
+
+		buf.WriteString(escapeMarkdown(text[last:m[0]]))
+
+		switch {
+		case m[2] >= 0:
+			buf.WriteString("`" + group(1) + "`")
+
+		case m[4] >= 0:
+			buf.WriteString("![" + escapeMarkdown(group(3)) + "](" + group(2) + ")")
+
+		case m[8] >= 0:
+			label := group(5)
+			if label == "" {
+				label = group(4)
+			}
+			buf.WriteString("[" + escapeMarkdown(label) + "](<" + group(4) + ">)")
+
+		case m[12] >= 0:
+			buf.WriteString("<" + group(6) + ">")
+
+		case m[14] >= 0:
+			buf.WriteString("**" + asciidocInlineToMarkdown(group(7)) + "**")
+
+		case m[16] >= 0:
+			buf.WriteString("*" + asciidocInlineToMarkdown(group(8)) + "*")
+
+		default:
+			// Shortcodes are passed to md2tex unchanged
+			buf.WriteString(text[m[0]:m[1]])
+		}
+
+		last = m[1]
+	}
+
+	buf.WriteString(escapeMarkdown(text[last:]))
+
+	return buf.String()
+}
+
+// escapeMarkdown escapes characters having a meaning in Markdown
+func escapeMarkdown(text string) string {
+	var buf strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdownSpecials, r) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+// isConstrained checks the formatting marks at start and end are not
+// surrounded by letters or digits
+func isConstrained(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+		return false
+	}
+
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+		return false
+	}
+
+	return true
+}
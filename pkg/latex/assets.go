@@ -0,0 +1,84 @@
+package latex
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+)
+
+// assetDir contains the directory uploaded assets are placed in when
+// packing the source
+const assetDir = "assets"
+
+// ValidateAssets checks the names of the uploaded assets to be plain
+// relative paths
+func ValidateAssets(assets map[string][]byte) error {
+	for name := range assets {
+		if _, err := assetPath(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assetPath returns the path of the uploaded asset in the packed source
+func assetPath(name string) (string, error) {
+	clean, err := md2tex.CleanImagePath(name)
+	if err != nil || clean == "." || name != clean {
+		return "", fmt.Errorf("invalid asset name %q", name)
+	}
+
+	return path.Join(assetDir, clean), nil
+}
+
+// imageResolver resolves images referenced in Markdown against the
+// files of the source-set and the uploaded assets (in this order)
+func imageResolver(sourceFiles fs.FS, assets map[string][]byte) md2tex.ImageResolver {
+	return func(p string) (string, error) {
+		clean, err := md2tex.CleanImagePath(p)
+		if err != nil {
+			return "", err //nolint:wrapcheck // Wrapped by md2tex
+		}
+
+		if info, err := fs.Stat(sourceFiles, clean); err == nil && !info.IsDir() && !strings.HasSuffix(clean, ".tpl") {
+			return clean, nil
+		}
+
+		if _, ok := assets[clean]; ok {
+			return assetPath(clean)
+		}
+
+		return "", fmt.Errorf("image %q not found in source-set or uploaded assets", p)
+	}
+}
+
+// previewImageExtensions contains the file types of the source-set
+// which can be served to display them in the preview
+var previewImageExtensions = []string{".gif", ".jpeg", ".jpg", ".png", ".svg", ".webp"}
+
+// ReadPreviewImage returns the content of an image of the source-set
+// to be displayed in the preview. Only images referenced relative to
+// the source-set are available.
+func ReadPreviewImage(base, name, p string) ([]byte, error) {
+	clean, err := md2tex.CleanImagePath(p)
+	if err != nil {
+		return nil, fmt.Errorf("checking path: %w", err)
+	}
+
+	if !slices.Contains(previewImageExtensions, strings.ToLower(path.Ext(clean))) {
+		return nil, fmt.Errorf("file %q is no image: %w", p, fs.ErrNotExist)
+	}
+
+	content, err := fs.ReadFile(os.DirFS(path.Join(base, name)), clean)
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,39 @@
+package latex
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageResolver(t *testing.T) {
+	resolve := imageResolver(fstest.MapFS{
+		"logo.png":     {Data: []byte("png")},
+		"main.tex.tpl": {Data: []byte("tpl")},
+	}, map[string][]byte{
+		"photo.jpg": []byte("jpg"),
+	})
+
+	p, err := resolve("./logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, "logo.png", p)
+
+	p, err = resolve("photo.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "assets/photo.jpg", p)
+
+	_, err = resolve("main.tex.tpl")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = resolve("missing.png")
+	assert.ErrorContains(t, err, `image "missing.png" not found`)
+}
+
+func TestValidateAssets(t *testing.T) {
+	assert.NoError(t, ValidateAssets(map[string][]byte{"logo.png": nil, "img/photo.jpg": nil}))
+	assert.Error(t, ValidateAssets(map[string][]byte{"../logo.png": nil}))
+	assert.Error(t, ValidateAssets(map[string][]byte{"./logo.png": nil}))
+	assert.Error(t, ValidateAssets(map[string][]byte{"": nil}))
+}
@@ -0,0 +1,114 @@
+package latex
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+)
+
+type (
+	// Converter converts text written in a markup language into LaTeX.
+	// The mdOpts contain the md2tex options of the render request
+	// (image resolver, shortcodes, sandbox) for converters building
+	// upon md2tex, the args are the additional arguments given to the
+	// template function (HTML policy and / or quote language).
+	Converter func(text string, mdOpts []md2tex.Option, args ...string) (string, error)
+)
+
+// DefaultMarkup is used for properties not specifying their markup
+// using the `x-markup` keyword
+const DefaultMarkup = "markdown"
+
+var (
+	converters = map[string]Converter{
+		"asciidoc": convertAsciiDoc,
+		"markdown": convertMarkdown,
+		"text":     convertText,
+	}
+	convertersLock sync.RWMutex
+
+	textParagraphSeparator = regexp.MustCompile(`\n[ \t]*\n\s*`)
+)
+
+// RegisterConverter adds a converter for the given markup name (or
+// replaces the existing one) to be used in `x-markup` and the
+// `convert` template function
+func RegisterConverter(name string, c Converter) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+
+	converters[name] = c
+}
+
+// Converters returns the names of all registered converters
+func Converters() []string {
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+
+	names := make([]string, 0, len(converters))
+	for name := range converters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Convert converts the text using the converter registered for the
+// markup name
+func Convert(markup, text string, mdOpts []md2tex.Option, args ...string) (string, error) {
+	convertersLock.RLock()
+	c, ok := converters[markup]
+	convertersLock.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown markup %q", markup)
+	}
+
+	return c(text, mdOpts, args...)
+}
+
+func convertAsciiDoc(text string, mdOpts []md2tex.Option, args ...string) (string, error) {
+	return convertMarkdown(asciidocToMarkdown(text), mdOpts, args...)
+}
+
+func convertMarkdown(text string, mdOpts []md2tex.Option, args ...string) (string, error) {
+	opts, err := markdownArgs(mdOpts, args)
+	if err != nil {
+		return "", err
+	}
+
+	tex, err := md2tex.Convert([]byte(text), opts...)
+	return string(tex), err //nolint:wrapcheck // Error is wrapped by the template engine
+}
+
+// convertText escapes plain text keeping its paragraphs (separated by
+// empty lines) and line breaks
+func convertText(text string, mdOpts []md2tex.Option, args ...string) (string, error) {
+	opts, err := markdownArgs(mdOpts, args)
+	if err != nil {
+		return "", err
+	}
+
+	text = strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text))
+	if text == "" {
+		return "", nil
+	}
+
+	paragraphs := textParagraphSeparator.Split(text, -1)
+	for i, paragraph := range paragraphs {
+		lines := strings.Split(paragraph, "\n")
+		for j := range lines {
+			lines[j] = md2tex.Escape(strings.TrimSpace(lines[j]), opts...)
+		}
+		// Lines might start with `[` or `*` which would be taken as
+		// arguments of `\\` so `\newline` is used
+		paragraphs[i] = strings.Join(lines, "\\newline{}\n")
+	}
+
+	return strings.Join(paragraphs, "\n\n"), nil
+}
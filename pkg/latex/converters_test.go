@@ -0,0 +1,139 @@
+package latex
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertText(t *testing.T) {
+	tex, err := Convert("text", "Dear *Jane*,\r\n[sic] 100 % \"sure\"\n  \n\n\nRegards\n  John", nil, "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Dear *Jane*,\\newline{}\n[sic] 100 \\% \\textquotedblleft{}sure\\textquotedblright{}\n\nRegards\\newline{}\nJohn", tex)
+}
+
+func TestConvertAsciiDoc(t *testing.T) {
+	src := `= Title
+
+A *bold* and _italic_ text with ` + "`code_here`" + `, snake_case_words and 2*3*4. +
+See https://example.com/a_b[the site] or https://example.com.
+# not a heading {% vspace 1cm %}
+
+* One
+** Nested
+* Two
+. First
+.. Sub
+
+[source,go]
+----
+fmt.Println("*x*")
+----
+
+image::logo.png[Logo]
+// comment
+'''
+`
+
+	assert.Equal(t, `
+# Title
+
+
+A **bold** and *italic* text with `+"`code_here`"+`, snake\_case\_words and 2\*3\*4.\
+See [the site](<https://example.com/a_b>) or <https://example.com>.
+\# not a heading {% vspace 1cm %}
+
+- One
+  - Nested
+- Two
+1. First
+   1. Sub
+
+`+"```go"+`
+fmt.Println("*x*")
+`+"```"+`
+
+
+![Logo](logo.png)
+
+
+---
+
+`, asciidocToMarkdown(src))
+
+	tex, err := Convert("asciidoc", src, []md2tex.Option{md2tex.WithImageResolver(md2tex.CleanImagePath)})
+	require.NoError(t, err)
+	assert.Contains(t, tex, `\section*{Title}`)
+	assert.Contains(t, tex, `A \textbf{bold} and \textit{italic}`)
+	assert.Contains(t, tex, `\# not a heading \vspace{1cm}`)
+}
+
+func TestConverterRegistry(t *testing.T) {
+	RegisterConverter("upper", func(text string, _ []md2tex.Option, _ ...string) (string, error) {
+		return md2tex.Escape(text) + "!", nil
+	})
+	t.Cleanup(func() {
+		convertersLock.Lock()
+		defer convertersLock.Unlock()
+		delete(converters, "upper")
+	})
+
+	assert.Equal(t, []string{"asciidoc", "markdown", "text", "upper"}, Converters())
+
+	_, err := Convert("unknown", "text", nil)
+	assert.Error(t, err)
+}
+
+func TestMarkupProperties(t *testing.T) {
+	src := fstest.MapFS{
+		"main.tex.tpl": {Data: []byte(`{{ markup "body" }}|{{ markup "mail" }}|{{ markup "missing" }}|{{ convert "text" .Values.mail }}`)},
+		"schema.json": {Data: []byte(`{"properties": {
+			"body": {"type": "string"},
+			"mail": {"type": "string", "x-markup": "text"}
+		}}`)},
+	}
+
+	tpl, _, err := readTemplate(src, "main.tex.tpl")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, executeTemplate(buf, src, tpl, RenderOpts{Values: map[string]any{
+		"body": "*Hello*",
+		"mail": "*Hello*\nWorld",
+	}}))
+	assert.Equal(t, "\\textit{Hello}|*Hello*\\newline{}\nWorld||*Hello*\\newline{}\nWorld", buf.String())
+
+	schema, err := readSchemaFS(src, "schema.json")
+	require.NoError(t, err)
+	mail, _ := schema.Properties.Get("mail")
+	assert.Equal(t, map[string]any{"x-markup": "text"}, mail.Extras)
+}
+
+func TestMarkupNestedProperties(t *testing.T) {
+	src := fstest.MapFS{
+		"main.tex.tpl": {Data: []byte(`{{ range $i, $item := .Values.items }}{{ markup (printf "items[%d].description" $i) }};{{ end }}{{ markup "customer.note" }}|{{ markup "items[5].description" }}`)},
+		"schema.json": {Data: []byte(`{"properties": {
+			"customer": {"type": "object", "properties": {"note": {"type": "string", "x-markup": "text"}}},
+			"items": {"type": "array", "items": {"type": "object", "properties": {
+				"description": {"type": "string", "x-markup": "text"}
+			}}}
+		}}`)},
+	}
+
+	tpl, _, err := readTemplate(src, "main.tex.tpl")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, executeTemplate(buf, src, tpl, RenderOpts{Values: map[string]any{
+		"customer": map[string]any{"note": "*a*"},
+		"items": []any{
+			map[string]any{"description": "*b*"},
+			map[string]any{"description": "c_d"},
+		},
+	}}))
+	assert.Equal(t, `*b*;c\_d;*a*|`, buf.String())
+}
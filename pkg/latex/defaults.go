@@ -0,0 +1,142 @@
+package latex
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+
+	"github.com/invopop/jsonschema"
+)
+
+// ApplyDefaults returns a copy of the values having all properties
+// missing in the values filled with the `default` of the property in
+// the schema. Defaults given as strings for number, integer or
+// boolean properties are converted into the type of the property.
+// Nested objects and the items of arrays get their defaults applied
+// the same way. Missing objects are only created if they are required
+// and their properties have defaults.
+func ApplyDefaults(schema *jsonschema.Schema, values map[string]any) map[string]any {
+	out := make(map[string]any, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+
+	if schema == nil || schema.Properties == nil {
+		return out
+	}
+
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if v := applyDefaultsValue(pair.Value, out[pair.Key], slices.Contains(schema.Required, pair.Key)); v != nil {
+			out[pair.Key] = v
+		}
+	}
+
+	return out
+}
+
+// applyDefaultsValue returns the value having the defaults of the
+// schema applied, a missing object is only created when required
+func applyDefaultsValue(schema *jsonschema.Schema, value any, required bool) any {
+	if schema == nil {
+		return value
+	}
+
+	if value == nil && schema.Default != nil {
+		value = typedDefault(schema.Type, copyValue(schema.Default))
+	}
+
+	switch v := value.(type) {
+	case nil:
+		if schema.Type != "object" || !required {
+			// Creating an optional object would make its required
+			// properties fail the validation
+			return nil
+		}
+
+		if nested := ApplyDefaults(schema, nil); len(nested) > 0 {
+			return nested
+		}
+		return nil
+
+	case map[string]any:
+		return ApplyDefaults(schema, v)
+
+	case []any:
+		if schema.Items == nil {
+			return v
+		}
+
+		out := make([]any, len(v))
+		for i := range v {
+			out[i] = applyDefaultsValue(schema.Items, v[i], true)
+		}
+		return out
+
+	default:
+		return v
+	}
+}
+
+// copyValue creates a deep copy of JSON decoded values in order not
+// to leak modifications of the values back into the schema
+func copyValue(v any) any {
+	switch tv := v.(type) {
+	case []any:
+		out := make([]any, len(tv))
+		for i := range tv {
+			out[i] = copyValue(tv[i])
+		}
+		return out
+
+	case map[string]any:
+		out := make(map[string]any, len(tv))
+		for k := range tv {
+			out[k] = copyValue(tv[k])
+		}
+		return out
+
+	default:
+		return v
+	}
+}
+
+func effectiveValues(sourceFiles fs.FS, values any) (any, error) {
+	mv, ok := values.(map[string]any)
+	if !ok && values != nil {
+		// We can only apply defaults to objects
+		return values, nil
+	}
+
+	schema, err := readSchemaFS(sourceFiles, "schema.json")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return values, nil
+		}
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+
+	return ApplyDefaults(schema, mv), nil
+}
+
+func typedDefault(schemaType string, v any) any {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+
+	switch schemaType {
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
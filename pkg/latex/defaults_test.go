@@ -0,0 +1,104 @@
+package latex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer", "default": "3"},
+			"enabled": {"type": "boolean", "default": true},
+			"subject": {"type": "string", "default": "Hello"},
+			"tags": {"type": "array", "default": ["a", "b"]}
+		}
+	}`), &schema))
+
+	values := ApplyDefaults(&schema, map[string]any{"subject": "World"})
+	assert.Equal(t, map[string]any{
+		"count":   float64(3),
+		"enabled": true,
+		"subject": "World",
+		"tags":    []any{"a", "b"},
+	}, values)
+
+	// Modifying the values must not modify the schema
+	values["tags"].([]any)[0] = "c"
+	assert.Equal(t, []any{"a", "b"}, ApplyDefaults(&schema, nil)["tags"])
+}
+
+func TestApplyNestedDefaults(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"customer": {
+				"type": "object",
+				"properties": {"country": {"type": "string", "default": "DE"}}
+			},
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {"quantity": {"type": "number", "default": "1"}}
+				}
+			},
+			"meta": {"type": "object", "properties": {"note": {"type": "string"}}}
+		},
+		"required": ["customer"]
+	}`), &schema))
+
+	values := map[string]any{
+		"items": []any{
+			map[string]any{"description": "a"},
+			map[string]any{"description": "b", "quantity": float64(3)},
+		},
+	}
+
+	assert.Equal(t, map[string]any{
+		"customer": map[string]any{"country": "DE"},
+		"items": []any{
+			map[string]any{"description": "a", "quantity": float64(1)},
+			map[string]any{"description": "b", "quantity": float64(3)},
+		},
+	}, ApplyDefaults(&schema, values))
+
+	// The given values must not be modified
+	assert.NotContains(t, values["items"].([]any)[0], "quantity")
+}
+
+func TestApplyDefaultsOptionalObject(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"delivery": {
+				"type": "object",
+				"properties": {
+					"country": {"type": "string", "default": "DE"},
+					"street": {"type": "string"}
+				},
+				"required": ["street"]
+			}
+		}
+	}`), &schema))
+
+	// An omitted optional object must not be created from defaults
+	values := ApplyDefaults(&schema, map[string]any{})
+	assert.Equal(t, map[string]any{}, values)
+	assert.NoError(t, ValidateValues(&schema, values))
+
+	// An object sent by the client gets its defaults
+	assert.Equal(t, map[string]any{
+		"delivery": map[string]any{"country": "DE", "street": "Musterweg"},
+	}, ApplyDefaults(&schema, map[string]any{
+		"delivery": map[string]any{"street": "Musterweg"},
+	}))
+}
@@ -0,0 +1,662 @@
+package latex
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/shopspring/decimal"
+)
+
+// moneyPlaces is the number of decimal places amounts are rounded to
+// when formatting money or calculating VAT
+const moneyPlaces = 2
+
+type (
+	// VATBreakdown contains the net, VAT and gross amounts of an
+	// invoice in total and grouped by VAT rate
+	VATBreakdown struct {
+		Rates []VATRate
+		Net   decimal.Decimal
+		VAT   decimal.Decimal
+		Gross decimal.Decimal
+	}
+
+	// VATRate contains the sums of all items having the same VAT rate
+	// (given in percent)
+	VATRate struct {
+		Rate  decimal.Decimal
+		Net   decimal.Decimal
+		VAT   decimal.Decimal
+		Gross decimal.Decimal
+	}
+
+	currencyNames struct {
+		one, other           string
+		minorOne, minorOther string
+	}
+
+	numberLocale struct {
+		decimalSep, groupSep string
+		// currencyPrefix places the currency in front of the amount
+		currencyPrefix bool
+	}
+)
+
+var (
+	currencySymbols = map[string]string{
+		"EUR": "€",
+		"GBP": "£",
+		"USD": "$",
+	}
+
+	currencyWords = map[string]map[string]currencyNames{
+		"de": {
+			"CHF": {"Franken", "Franken", "Rappen", "Rappen"},
+			"EUR": {"Euro", "Euro", "Cent", "Cent"},
+			"GBP": {"Pfund", "Pfund", "Penny", "Pence"},
+			"USD": {"US-Dollar", "US-Dollar", "Cent", "Cent"},
+		},
+		"en": {
+			"CHF": {"franc", "francs", "centime", "centimes"},
+			"EUR": {"euro", "euros", "cent", "cents"},
+			"GBP": {"pound", "pounds", "penny", "pence"},
+			"USD": {"dollar", "dollars", "cent", "cents"},
+		},
+	}
+
+	numberLocales = map[string]numberLocale{
+		"de": {decimalSep: ",", groupSep: "."},
+		"en": {decimalSep: ".", groupSep: ",", currencyPrefix: true},
+		"fr": {decimalSep: ",", groupSep: "\u202f"},
+	}
+
+	numberWords = map[string]func(int64) (string, error){
+		"de": germanNumberWords,
+		"en": englishNumberWords,
+	}
+
+	roundingModes = map[string]func(d decimal.Decimal, places int32) decimal.Decimal{
+		"ceil":      decimal.Decimal.RoundCeil,
+		"down":      decimal.Decimal.RoundDown,
+		"floor":     decimal.Decimal.RoundFloor,
+		"half-even": decimal.Decimal.RoundBank,
+		"half-up":   decimal.Decimal.Round,
+		"up":        decimal.Decimal.RoundUp,
+	}
+)
+
+// invoiceFuncs returns the template functions for calculations with
+// money: all of them take numbers, numeric strings or decimals and
+// calculate without the rounding errors of floats
+func invoiceFuncs() template.FuncMap {
+	return template.FuncMap{
+		"amountInWords": amountInWords,
+		"decAdd":        decAdd,
+		"decCmp":        decCmp,
+		"decDiv":        decDiv,
+		"decimal":       toDecimal,
+		"decMul":        decMul,
+		"decPercent":    decPercent,
+		"decRound":      decRound,
+		"decSub":        decSub,
+		"decSum":        decSum,
+		"formatDecimal": formatDecimal,
+		"formatMoney":   formatMoney,
+		"vatBreakdown":  vatBreakdown,
+	}
+}
+
+// amountInWords spells out the amount (i.e. for cheques or to state
+// the total in words) in the language of the locale
+func amountInWords(value any, currency, locale string) (string, error) {
+	d, err := toDecimal(value)
+	if err != nil {
+		return "", err
+	}
+
+	lang := localeLanguage(locale)
+	words, ok := numberWords[lang]
+	if !ok {
+		return "", fmt.Errorf("amount in words is not supported for locale %q", locale)
+	}
+
+	names, ok := currencyWords[lang][strings.ToUpper(currency)]
+	if !ok {
+		return "", fmt.Errorf("amount in words is not supported for currency %q", currency)
+	}
+
+	d = d.Round(moneyPlaces)
+	var parts []string
+	if d.IsNegative() {
+		parts = append(parts, "minus")
+		d = d.Neg()
+	}
+
+	units := d.IntPart()
+	minor := d.Sub(decimal.NewFromInt(units)).Shift(moneyPlaces).IntPart()
+
+	unitWords, err := spellCount(words, lang, units)
+	if err != nil {
+		return "", err
+	}
+	parts = append(parts, unitWords, pluralize(units, names.one, names.other))
+
+	if minor > 0 {
+		minorWords, err := spellCount(words, lang, minor)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, map[string]string{"de": "und", "en": "and"}[lang], minorWords, pluralize(minor, names.minorOne, names.minorOther))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// decAdd returns the sum of all values
+func decAdd(a any, values ...any) (decimal.Decimal, error) {
+	return reduceDecimals(a, values, decimal.Decimal.Add)
+}
+
+// decCmp returns -1, 0 or 1 if a is less than, equal to or greater
+// than b
+func decCmp(a, b any) (int, error) {
+	da, err := toDecimal(a)
+	if err != nil {
+		return 0, err
+	}
+
+	db, err := toDecimal(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return da.Cmp(db), nil
+}
+
+// decDiv divides a by b
+func decDiv(a, b any) (decimal.Decimal, error) {
+	db, err := toDecimal(b)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if db.IsZero() {
+		return decimal.Zero, fmt.Errorf("division by zero")
+	}
+
+	return reduceDecimals(a, []any{db}, decimal.Decimal.Div)
+}
+
+// decMul returns the product of all values
+func decMul(a any, values ...any) (decimal.Decimal, error) {
+	return reduceDecimals(a, values, decimal.Decimal.Mul)
+}
+
+// decPercent returns the given percentage of the value (i.e. the VAT
+// or a discount) without rounding
+func decPercent(value, percent any) (decimal.Decimal, error) {
+	return reduceDecimals(value, []any{percent}, func(v, p decimal.Decimal) decimal.Decimal {
+		return v.Mul(p).Div(decimal.NewFromInt(100))
+	})
+}
+
+// decRound rounds the value to the given number of decimal places. The
+// mode defaults to `half-up` (commercial rounding), also available are
+// `half-even` (banker's rounding), `up` / `down` (away from / towards
+// zero) and `ceil` / `floor`.
+func decRound(value any, places int, mode ...string) (decimal.Decimal, error) {
+	d, err := toDecimal(value)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	m := "half-up"
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+
+	round, ok := roundingModes[m]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("unknown rounding mode %q", m)
+	}
+
+	return round(d, int32(places)), nil //#nosec:G115 // Places are small numbers given in the template
+}
+
+// decSub subtracts all values from a
+func decSub(a any, values ...any) (decimal.Decimal, error) {
+	return reduceDecimals(a, values, decimal.Decimal.Sub)
+}
+
+// decSum returns the sum of the list, if a key is given the list must
+// contain objects and their values of the key are summed up
+func decSum(list any, key ...string) (decimal.Decimal, error) {
+	items, err := toList(list)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	sum := decimal.Zero
+	for i, item := range items {
+		if len(key) > 0 {
+			if item, err = itemValue(item, key[0]); err != nil {
+				return decimal.Zero, fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+
+		d, err := toDecimal(item)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("item %d: %w", i, err)
+		}
+		sum = sum.Add(d)
+	}
+
+	return sum, nil
+}
+
+// formatDecimal formats the value rounded (half-up) to the given
+// number of places using the separators of the locale (`de`, `en` or
+// `fr`, region suffixes like `de-AT` are accepted)
+func formatDecimal(value any, places int, locale string) (string, error) {
+	d, err := toDecimal(value)
+	if err != nil {
+		return "", err
+	}
+
+	loc, ok := numberLocales[localeLanguage(locale)]
+	if !ok {
+		return "", fmt.Errorf("unsupported locale %q", locale)
+	}
+
+	var sign string
+	d = d.Round(int32(places)) //#nosec:G115 // Places are small numbers given in the template
+	if d.IsNegative() {
+		sign = "-"
+		d = d.Neg()
+	}
+
+	intPart, frac, _ := strings.Cut(d.StringFixed(int32(places)), ".") //#nosec:G115 // See above
+
+	var grouped []string
+	for len(intPart) > 3 {
+		grouped = append([]string{intPart[len(intPart)-3:]}, grouped...)
+		intPart = intPart[:len(intPart)-3]
+	}
+	grouped = append([]string{intPart}, grouped...)
+
+	out := sign + strings.Join(grouped, loc.groupSep)
+	if frac != "" {
+		out += loc.decimalSep + frac
+	}
+
+	return out, nil
+}
+
+// formatMoney formats the amount in the currency (ISO code like `EUR`)
+// the way the locale does: `1.234,56 €` for `de`, `€1,234.56` for `en`.
+// The currency is separated by a non-breaking space.
+func formatMoney(value any, currency, locale string) (string, error) {
+	amount, err := formatDecimal(value, moneyPlaces, locale)
+	if err != nil {
+		return "", err
+	}
+
+	symbol, ok := currencySymbols[strings.ToUpper(currency)]
+	if !ok {
+		symbol = strings.ToUpper(currency)
+	}
+
+	if !numberLocales[localeLanguage(locale)].currencyPrefix {
+		return amount + "\u00a0" + symbol, nil
+	}
+
+	if !ok {
+		// Currency codes are separated from the amount
+		symbol += "\u00a0"
+	}
+
+	if abs, negative := strings.CutPrefix(amount, "-"); negative {
+		return "-" + symbol + abs, nil
+	}
+
+	return symbol + amount, nil
+}
+
+// vatBreakdown sums up the net amounts of the items (objects having the
+// net price and the VAT rate in percent at the given keys) by VAT rate
+// and calculates the VAT per rate. When a quantity key is given the net
+// amount of the item is the price multiplied by the quantity. Amounts
+// of items and VAT are rounded (half-up) to cents.
+func vatBreakdown(list any, priceKey, rateKey string, quantityKey ...string) (b VATBreakdown, err error) {
+	items, err := toList(list)
+	if err != nil {
+		return b, err
+	}
+
+	rates := map[string]*VATRate{}
+	for i, item := range items {
+		net, rate, err := vatItem(item, priceKey, rateKey, quantityKey)
+		if err != nil {
+			return b, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		r, ok := rates[rate.String()]
+		if !ok {
+			r = &VATRate{Rate: rate}
+			rates[rate.String()] = r
+		}
+		r.Net = r.Net.Add(net)
+	}
+
+	for _, r := range rates {
+		r.VAT = r.Net.Mul(r.Rate).Div(decimal.NewFromInt(100)).Round(moneyPlaces)
+		r.Gross = r.Net.Add(r.VAT)
+
+		b.Rates = append(b.Rates, *r)
+		b.Net = b.Net.Add(r.Net)
+		b.VAT = b.VAT.Add(r.VAT)
+		b.Gross = b.Gross.Add(r.Gross)
+	}
+
+	sort.Slice(b.Rates, func(i, j int) bool { return b.Rates[i].Rate.LessThan(b.Rates[j].Rate) })
+
+	return b, nil
+}
+
+func englishNumberWords(n int64) (string, error) {
+	var (
+		ones = []string{
+			"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+			"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+		}
+		tens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	)
+
+	belowThousand := func(n int64) string {
+		var parts []string
+		if h := n / 100; h > 0 {
+			parts = append(parts, ones[h]+" hundred")
+		}
+
+		switch r := n % 100; {
+		case r == 0:
+		case r < 20:
+			parts = append(parts, ones[r])
+		case r%10 == 0:
+			parts = append(parts, tens[r/10])
+		default:
+			parts = append(parts, tens[r/10]+"-"+ones[r%10])
+		}
+
+		return strings.Join(parts, " ")
+	}
+
+	if n == 0 {
+		return ones[0], nil
+	}
+
+	if n >= 1e15 {
+		return "", fmt.Errorf("number %d is too large", n)
+	}
+
+	var parts []string
+	for _, scale := range []struct {
+		value int64
+		name  string
+	}{
+		{1e12, "trillion"},
+		{1e9, "billion"},
+		{1e6, "million"},
+		{1e3, "thousand"},
+		{1, ""},
+	} {
+		c := n / scale.value % 1000
+		if c == 0 {
+			continue
+		}
+
+		parts = append(parts, strings.TrimSpace(belowThousand(c)+" "+scale.name))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func germanNumberWords(n int64) (string, error) {
+	var (
+		ones = []string{
+			"null", "eins", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun", "zehn",
+			"elf", "zwölf", "dreizehn", "vierzehn", "fünfzehn", "sechzehn", "siebzehn", "achtzehn", "neunzehn",
+		}
+		tens = []string{"", "", "zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig", "neunzig"}
+	)
+
+	// unit returns the digit as used within compounds (`ein` instead
+	// of `eins`)
+	unit := func(n int64) string {
+		if n == 1 {
+			return "ein"
+		}
+		return ones[n]
+	}
+
+	// belowThousand returns the words for the number, final controls
+	// whether a trailing one is spelled `eins` (end of the number) or
+	// `ein` (followed by `tausend`)
+	belowThousand := func(n int64, final bool) string {
+		var out string
+		if h := n / 100; h > 0 {
+			out = unit(h) + "hundert"
+		}
+
+		switch r := n % 100; {
+		case r == 0:
+		case r == 1 && !final:
+			out += "ein"
+		case r < 20:
+			out += ones[r]
+		case r%10 == 0:
+			out += tens[r/10]
+		default:
+			out += unit(r%10) + "und" + tens[r/10]
+		}
+
+		return out
+	}
+
+	if n == 0 {
+		return ones[0], nil
+	}
+
+	if n >= 1e15 {
+		return "", fmt.Errorf("number %d is too large", n)
+	}
+
+	var parts []string
+	for _, scale := range []struct {
+		value       int64
+		one, plural string
+	}{
+		{1e12, "Billion", "Billionen"},
+		{1e9, "Milliarde", "Milliarden"},
+		{1e6, "Million", "Millionen"},
+	} {
+		switch c := n / scale.value % 1000; c {
+		case 0:
+		case 1:
+			parts = append(parts, "eine "+scale.one)
+		default:
+			parts = append(parts, belowThousand(c, false)+" "+scale.plural)
+		}
+	}
+
+	var rest string
+	if t := n / 1000 % 1000; t > 0 {
+		rest = belowThousand(t, false) + "tausend"
+	}
+	rest += belowThousand(n%1000, true)
+
+	if rest != "" {
+		parts = append(parts, rest)
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// itemValue returns the value of the key of an object in a list
+func itemValue(item any, key string) (any, error) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %T", item)
+	}
+
+	if m[key] == nil {
+		// Must not silently be treated as zero by toDecimal
+		return nil, fmt.Errorf("missing %q", key)
+	}
+
+	return m[key], nil
+}
+
+// localeLanguage returns the language of locales like `de-AT` or `de_AT`
+func localeLanguage(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(locale), "_", "-"), "-")
+	return lang
+}
+
+func pluralize(n int64, one, other string) string {
+	if n == 1 {
+		return one
+	}
+	return other
+}
+
+func reduceDecimals(a any, values []any, fn func(a, b decimal.Decimal) decimal.Decimal) (decimal.Decimal, error) {
+	result, err := toDecimal(a)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	for _, v := range values {
+		d, err := toDecimal(v)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		result = fn(result, d)
+	}
+
+	return result, nil
+}
+
+// spellCount returns the words for a count of currency units: in
+// German a single unit is `ein Euro` instead of `eins Euro`
+func spellCount(words func(int64) (string, error), lang string, n int64) (string, error) {
+	if lang == "de" && n == 1 {
+		return "ein", nil
+	}
+
+	return words(n)
+}
+
+// toDecimal converts numbers, numeric strings and decimals into a
+// decimal, empty values are treated as zero
+func toDecimal(v any) (decimal.Decimal, error) {
+	switch tv := v.(type) {
+	case nil:
+		return decimal.Zero, nil
+
+	case decimal.Decimal:
+		return tv, nil
+
+	case *decimal.Decimal:
+		return *tv, nil
+
+	case float32:
+		return decimal.NewFromFloat32(tv), nil
+
+	case float64:
+		return decimal.NewFromFloat(tv), nil
+
+	case int:
+		return decimal.NewFromInt(int64(tv)), nil
+
+	case int64:
+		return decimal.NewFromInt(tv), nil
+
+	case json.Number:
+		return toDecimal(tv.String())
+
+	case string:
+		if strings.TrimSpace(tv) == "" {
+			return decimal.Zero, nil
+		}
+
+		d, err := decimal.NewFromString(strings.TrimSpace(tv))
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("parsing %q as decimal: %w", tv, err)
+		}
+		return d, nil
+
+	default:
+		return decimal.Zero, fmt.Errorf("cannot convert %T to decimal", v)
+	}
+}
+
+// toList converts slices of any type into a list of values
+func toList(v any) ([]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected list, got %T", v)
+	}
+
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+
+	return out, nil
+}
+
+// vatItem returns the rounded net amount and VAT rate of the item
+func vatItem(item any, priceKey, rateKey string, quantityKey []string) (net, rate decimal.Decimal, err error) {
+	price, err := itemValue(item, priceKey)
+	if err != nil {
+		return net, rate, err
+	}
+
+	if net, err = toDecimal(price); err != nil {
+		return net, rate, fmt.Errorf("price: %w", err)
+	}
+
+	if len(quantityKey) > 0 {
+		quantity, err := itemValue(item, quantityKey[0])
+		if err != nil {
+			return net, rate, err
+		}
+
+		q, err := toDecimal(quantity)
+		if err != nil {
+			return net, rate, fmt.Errorf("quantity: %w", err)
+		}
+		net = net.Mul(q)
+	}
+
+	rateValue, err := itemValue(item, rateKey)
+	if err != nil {
+		return net, rate, err
+	}
+
+	if rate, err = toDecimal(rateValue); err != nil {
+		return net, rate, fmt.Errorf("rate: %w", err)
+	}
+
+	return net.Round(moneyPlaces), rate, nil
+}
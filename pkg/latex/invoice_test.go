@@ -0,0 +1,171 @@
+package latex
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalArithmetic(t *testing.T) {
+	sum, err := decAdd(0.1, "0.2")
+	require.NoError(t, err)
+	assert.Equal(t, "0.3", sum.String())
+
+	product, err := decMul("19.99", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "59.97", product.String())
+
+	percent, err := decPercent("59.97", 19)
+	require.NoError(t, err)
+	assert.Equal(t, "11.3943", percent.String())
+
+	_, err = decDiv(1, "0")
+	assert.Error(t, err)
+
+	total, err := decSum([]any{
+		map[string]any{"price": "10.10"},
+		map[string]any{"price": 0.2},
+	}, "price")
+	require.NoError(t, err)
+	assert.Equal(t, "10.3", total.String())
+
+	_, err = decAdd(1, "abc")
+	assert.Error(t, err)
+
+	// Explicit nil values are zero, missing keys are an error
+	sum, err = decAdd(nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1", sum.String())
+
+	_, err = decSum([]any{
+		map[string]any{"price": "10.10"},
+		map[string]any{"amount": "1"},
+	}, "price")
+	assert.EqualError(t, err, `item 1: missing "price"`)
+}
+
+func TestDecimalRound(t *testing.T) {
+	for _, tc := range []struct {
+		value, mode, expected string
+	}{
+		{"2.345", "half-up", "2.35"},
+		{"-2.345", "half-up", "-2.35"},
+		{"2.345", "half-even", "2.34"},
+		{"2.341", "up", "2.35"},
+		{"2.349", "down", "2.34"},
+		{"-2.341", "ceil", "-2.34"},
+		{"-2.341", "floor", "-2.35"},
+	} {
+		d, err := decRound(tc.value, 2, tc.mode)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, d.String(), "%s %s", tc.value, tc.mode)
+	}
+
+	_, err := decRound(1, 2, "unknown")
+	assert.Error(t, err)
+}
+
+func TestFormatMoney(t *testing.T) {
+	for _, tc := range []struct {
+		value    any
+		currency string
+		locale   string
+		expected string
+	}{
+		{1234.56, "EUR", "de", "1.234,56\u00a0€"},
+		{"1234.56", "USD", "en-US", "$1,234.56"},
+		{"-1234.5", "USD", "en", "-$1,234.50"},
+		{"1234567.891", "CHF", "en", "CHF\u00a01,234,567.89"},
+		{"1234.5", "EUR", "fr_FR", "1\u202f234,50\u00a0€"},
+		{"0.005", "EUR", "de", "0,01\u00a0€"},
+	} {
+		out, err := formatMoney(tc.value, tc.currency, tc.locale)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, out)
+	}
+
+	_, err := formatMoney(1, "EUR", "xx")
+	assert.Error(t, err)
+}
+
+func TestVATBreakdown(t *testing.T) {
+	b, err := vatBreakdown([]any{
+		map[string]any{"price": "19.99", "quantity": float64(3), "vat": float64(19)},
+		map[string]any{"price": "4.50", "quantity": float64(2), "vat": "7"},
+		map[string]any{"price": "100", "quantity": float64(1), "vat": float64(19)},
+	}, "price", "vat", "quantity")
+	require.NoError(t, err)
+
+	require.Len(t, b.Rates, 2)
+	assert.Equal(t, "7", b.Rates[0].Rate.String())
+	assert.Equal(t, "9", b.Rates[0].Net.String())
+	assert.Equal(t, "0.63", b.Rates[0].VAT.String())
+	assert.Equal(t, "19", b.Rates[1].Rate.String())
+	assert.Equal(t, "159.97", b.Rates[1].Net.String())
+	assert.Equal(t, "30.39", b.Rates[1].VAT.String())
+	assert.Equal(t, "168.97", b.Net.String())
+	assert.Equal(t, "31.02", b.VAT.String())
+	assert.Equal(t, "199.99", b.Gross.String())
+
+	_, err = vatBreakdown([]any{"text"}, "price", "vat")
+	assert.Error(t, err)
+
+	for key, item := range map[string]map[string]any{
+		"price":    {"quantity": float64(1), "vat": float64(19)},
+		"vat":      {"price": "10", "quantity": float64(1)},
+		"quantity": {"price": "10", "vat": float64(19)},
+	} {
+		_, err = vatBreakdown([]any{
+			map[string]any{"price": "1", "quantity": float64(1), "vat": float64(19)},
+			item,
+		}, "price", "vat", "quantity")
+		assert.EqualError(t, err, `item 1: missing "`+key+`"`)
+	}
+}
+
+func TestAmountInWords(t *testing.T) {
+	for _, tc := range []struct {
+		value    any
+		currency string
+		locale   string
+		expected string
+	}{
+		{"1234.56", "EUR", "de", "eintausendzweihundertvierunddreißig Euro und sechsundfünfzig Cent"},
+		{1, "EUR", "de", "ein Euro"},
+		{"101.01", "EUR", "de", "einhunderteins Euro und ein Cent"},
+		{"2001001", "CHF", "de", "zwei Millionen eintausendeins Franken"},
+		{"1000000", "EUR", "de", "eine Million Euro"},
+		{"-0.5", "EUR", "de", "minus null Euro und fünfzig Cent"},
+		{"1234.56", "USD", "en", "one thousand two hundred thirty-four dollars and fifty-six cents"},
+		{"1.01", "GBP", "en-GB", "one pound and one penny"},
+		{"2000000", "EUR", "en", "two million euros"},
+	} {
+		out, err := amountInWords(tc.value, tc.currency, tc.locale)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, out)
+	}
+
+	_, err := amountInWords(1, "EUR", "fr")
+	assert.Error(t, err)
+
+	_, err = amountInWords(1, "JPY", "de")
+	assert.Error(t, err)
+}
+
+func TestInvoiceTemplateFuncs(t *testing.T) {
+	tpl, err := template.New("invoice").Funcs(templateFuncs(templateContext{})).Parse(
+		`{{ $vat := vatBreakdown .items "price" "vat" "quantity" }}` +
+			`{{ range $vat.Rates }}{{ .Rate }}%: {{ formatMoney .VAT "EUR" "de" | texEscape }};{{ end }}` +
+			`{{ formatMoney $vat.Gross "EUR" "de" | texEscape }}`,
+	)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, tpl.Execute(buf, map[string]any{"items": []any{
+		map[string]any{"price": "1000", "quantity": float64(2), "vat": float64(19)},
+	}}))
+	assert.Equal(t, `19%: 380,00~\texteuro{};2.380,00~\texteuro{}`, buf.String())
+}
@@ -1,17 +1,18 @@
 // Package latex contains a rendering helper for the letter source
-// using a hosted TeX-API instance
+// using a hosted TeX-API instance or a locally installed TeX engine
 package latex
 
 import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"net/http"
 	"os"
 	"path"
+	"sort"
 	"text/template"
 
 	"github.com/Luzifer/doc-render/pkg/recipientcsv"
@@ -21,7 +22,10 @@ import (
 type (
 	// RenderOpts define what to render into the template
 	RenderOpts struct {
-		// Instance of the TeX-API to use for rendering
+		// Renderer to compile the document with
+		Renderer Renderer
+		// Instance of the TeX-API to use for rendering (used when no
+		// Renderer is set)
 		TexAPIURL string
 
 		// Folder containing the source-sets
@@ -29,40 +33,142 @@ type (
 		// Source-set to include in the zip
 		SourceSet string
 
+		// Assets contains additional files (i.e. images) uploaded with
+		// the render request, they are placed in the `assets` directory
+		// next to the template
+		Assets map[string][]byte
+
+		// Sandbox restricts the document to be rendered from untrusted
+		// input: the raw shortcode is disabled and values containing
+		// forbidden control sequences are rejected. The sandbox can also
+		// be enabled by the source-set using `"x-sandbox": true` in its
+		// schema.
+		Sandbox bool
+
 		// Recipients contains the recipients for the letter (might not be
 		// supported by the chosen template)
 		Recipients []recipientcsv.Person
 		// Values to be used in the template (usage depends on the template)
 		Values any
 	}
+
+	// TemplateError is returned when the template of the source-set
+	// cannot be parsed or executed
+	TemplateError struct {
+		Err error
+	}
 )
 
+func (t TemplateError) Error() string {
+	return fmt.Sprintf("template error: %s", t.Err)
+}
+
+func (t TemplateError) Unwrap() error { return t.Err }
+
 // Render takes the options and the included template / source files,
-// generate the TeX document and renders it through the provided API.
+// generate the TeX document and renders it through the provided
+// Renderer (or the TeX-API if none is provided).
 //
 // The returned io.ReadCloser MUST be closed after usage to free up resources.
 func Render(ctx context.Context, opts RenderOpts) (pdf io.ReadCloser, err error) {
-	sourceFiles := os.DirFS(path.Join(opts.SourceBaseFolder, opts.SourceSet))
+	sourceFiles := sourceFS(opts)
 
-	tpl, err := readTemplate(sourceFiles, "main.tex.tpl")
+	tpl, tplSource, err := readTemplate(sourceFiles, "main.tex.tpl")
 	if err != nil {
 		return nil, fmt.Errorf("reading template: %w", err)
 	}
 
+	tex := new(bytes.Buffer)
+	if err = executeTemplate(tex, sourceFiles, tpl, opts); err != nil {
+		return nil, err
+	}
+
 	// Prepare a ZIP to upload to the API
 	zipFile := new(bytes.Buffer)
-	if err = packSource(zipFile, sourceFiles, tpl, opts); err != nil {
+	if err = packSource(zipFile, sourceFiles, opts.Assets, tex.Bytes()); err != nil {
 		return nil, fmt.Errorf("building ZIP: %w", err)
 	}
 
-	if pdf, err = renderDocument(ctx, opts, zipFile); err != nil {
+	renderer := opts.Renderer
+	if renderer == nil {
+		renderer = NewTexAPIRenderer(opts.TexAPIURL)
+	}
+
+	if pdf, err = renderer.Render(ctx, zipFile); err != nil {
+		var cErr CompileError
+		if errors.As(err, &cErr) {
+			cErr.Messages = mapTemplateLines(ParseLog(cErr.Log), tex.Bytes(), tplSource)
+			err = cErr
+		}
 		return nil, fmt.Errorf("rendering PDF: %w", err)
 	}
 
 	return pdf, nil
 }
 
-func packSource(dst io.Writer, sourceFiles fs.FS, tpl *template.Template, opts RenderOpts) (err error) {
+// Pack takes the options and the included template / source files,
+// generates the TeX document and writes the ZIP archive as it would
+// be passed to the Renderer
+func Pack(dst io.Writer, opts RenderOpts) error {
+	sourceFiles := sourceFS(opts)
+
+	tpl, _, err := readTemplate(sourceFiles, "main.tex.tpl")
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+
+	tex := new(bytes.Buffer)
+	if err = executeTemplate(tex, sourceFiles, tpl, opts); err != nil {
+		return err
+	}
+
+	return packSource(dst, sourceFiles, opts.Assets, tex.Bytes())
+}
+
+// RenderTeX takes the options and the included template and writes
+// the generated TeX document (`main.tex`) without rendering it
+func RenderTeX(dst io.Writer, opts RenderOpts) error {
+	sourceFiles := sourceFS(opts)
+
+	tpl, _, err := readTemplate(sourceFiles, "main.tex.tpl")
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+
+	return executeTemplate(dst, sourceFiles, tpl, opts)
+}
+
+func executeTemplate(dst io.Writer, sourceFiles fs.FS, tpl *template.Template, opts RenderOpts) (err error) {
+	if opts.Values, err = effectiveValues(sourceFiles, opts.Values); err != nil {
+		return fmt.Errorf("applying defaults: %w", err)
+	}
+
+	mdOpts, _, err := markdownOptions(sourceFiles, opts)
+	if err != nil {
+		return err
+	}
+
+	markups, err := readMarkups(sourceFiles)
+	if err != nil {
+		return fmt.Errorf("reading markups: %w", err)
+	}
+
+	// The conversions depend on the render request so the functions
+	// are bound to it before executing the template
+	tpl = tpl.Funcs(templateFuncs(templateContext{
+		markups: markups,
+		mdOpts:  mdOpts,
+		values:  opts.Values,
+	}))
+
+	if err = tpl.Execute(dst, opts); err != nil {
+		return TemplateError{Err: err}
+	}
+
+	return nil
+}
+
+func packSource(dst io.Writer, sourceFiles fs.FS, assets map[string][]byte, tex []byte) (err error) {
 	zw := zip.NewWriter(dst)
 
 	// Add all files from the source (including the template which will
@@ -71,14 +177,37 @@ func packSource(dst io.Writer, sourceFiles fs.FS, tpl *template.Template, opts R
 		return fmt.Errorf("adding source-files: %w", err)
 	}
 
+	// Add the uploaded assets in sorted order to get reproducible archives
+	names := make([]string, 0, len(assets))
+	for name := range assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, err := assetPath(name)
+		if err != nil {
+			return err
+		}
+
+		f, err := zw.Create(p)
+		if err != nil {
+			return fmt.Errorf("creating asset %s: %w", p, err)
+		}
+
+		if _, err = f.Write(assets[name]); err != nil {
+			return fmt.Errorf("writing asset %s: %w", p, err)
+		}
+	}
+
 	// Add the TeX document
 	texFile, err := zw.Create("main.tex")
 	if err != nil {
 		return fmt.Errorf("creating main.tex: %w", err)
 	}
 
-	if err = tpl.Execute(texFile, opts); err != nil {
-		return fmt.Errorf("rendering template: %w", err)
+	if _, err = texFile.Write(tex); err != nil {
+		return fmt.Errorf("writing main.tex: %w", err)
 	}
 
 	// Close and finalize archive
@@ -89,10 +218,10 @@ func packSource(dst io.Writer, sourceFiles fs.FS, tpl *template.Template, opts R
 	return nil
 }
 
-func readTemplate(src fs.FS, name string) (*template.Template, error) {
+func readTemplate(src fs.FS, name string) (*template.Template, []byte, error) {
 	f, err := src.Open(name)
 	if err != nil {
-		return nil, fmt.Errorf("opening template file: %w", err)
+		return nil, nil, fmt.Errorf("opening template file: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -102,39 +231,19 @@ func readTemplate(src fs.FS, name string) (*template.Template, error) {
 
 	tplSource, err := io.ReadAll(f)
 	if err != nil {
-		return nil, fmt.Errorf("reading template: %w", err)
+		return nil, nil, fmt.Errorf("reading template: %w", err)
 	}
 
-	tpl, err := template.New("letter").Funcs(templateFuncs()).Parse(string(tplSource))
+	tpl, err := template.New("letter").
+		Funcs(templateFuncs(templateContext{})).
+		Parse(string(tplSource))
 	if err != nil {
-		return nil, fmt.Errorf("parsing template: %w", err)
+		return nil, nil, TemplateError{Err: err}
 	}
 
-	return tpl, nil
+	return tpl, tplSource, nil
 }
 
-func renderDocument(ctx context.Context, opts RenderOpts, zipFile io.Reader) (pdf io.ReadCloser, err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.TexAPIURL, zipFile)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Accept", "application/pdf")
-	req.Header.Set("Content-Type", "application/zip")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			body = []byte(fmt.Sprintf("reading body: %s", err))
-		}
-		_ = resp.Body.Close()
-		return nil, fmt.Errorf("unexpected status: %d (%s)", resp.StatusCode, body)
-	}
-
-	return resp.Body, nil
+func sourceFS(opts RenderOpts) fs.FS {
+	return os.DirFS(path.Join(opts.SourceBaseFolder, opts.SourceSet))
 }
@@ -0,0 +1,226 @@
+package latex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLocalPasses  = 2
+	defaultLocalTimeout = time.Minute
+)
+
+type (
+	// LocalRenderer implements the Renderer interface by unpacking the
+	// archive into a temporary directory and executing a locally
+	// installed TeX engine on it
+	LocalRenderer struct {
+		command []string
+		passes  int
+		timeout time.Duration
+	}
+)
+
+var (
+	_ Renderer = LocalRenderer{}
+
+	// localEngines maps the engine names to the command to execute
+	// for one pass of the engine
+	localEngines = map[string][]string{
+		"latexmk":          {"latexmk", "-pdf"},
+		"latexmk-lualatex": {"latexmk", "-lualatex"},
+		"latexmk-xelatex":  {"latexmk", "-xelatex"},
+		"lualatex":         {"lualatex"},
+		"pdflatex":         {"pdflatex"},
+		"xelatex":          {"xelatex"},
+	}
+
+	// commonEngineArgs are passed to every engine to prevent it from
+	// waiting for user input in case of errors
+	commonEngineArgs = []string{
+		"-interaction=nonstopmode",
+		"-halt-on-error",
+		"-file-line-error",
+	}
+)
+
+// IsLocalEngine returns whether the given engine name is known to the
+// LocalRenderer
+func IsLocalEngine(engine string) bool {
+	_, ok := localEngines[engine]
+	return ok
+}
+
+// NewLocalRenderer creates a new LocalRenderer for the given engine.
+// The engine is executed `passes` times (latexmk is executed once as
+// it handles re-runs itself) and all passes together are limited to
+// the given timeout. Zero-values for passes and timeout are replaced
+// by sensible defaults.
+func NewLocalRenderer(engine string, passes int, timeout time.Duration) (LocalRenderer, error) {
+	command, ok := localEngines[engine]
+	if !ok {
+		return LocalRenderer{}, fmt.Errorf("unknown engine %q", engine)
+	}
+
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return LocalRenderer{}, fmt.Errorf("finding engine executable: %w", err)
+	}
+
+	if passes < 1 {
+		passes = defaultLocalPasses
+	}
+
+	if command[0] == "latexmk" {
+		passes = 1
+	}
+
+	if timeout <= 0 {
+		timeout = defaultLocalTimeout
+	}
+
+	return LocalRenderer{
+		command: command,
+		passes:  passes,
+		timeout: timeout,
+	}, nil
+}
+
+// Render unpacks the archive, executes the engine and returns the
+// resulting PDF
+func (l LocalRenderer) Render(ctx context.Context, zipFile io.Reader) (pdf io.ReadCloser, err error) {
+	workDir, err := os.MkdirTemp("", "doc-render-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating work directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			logrus.WithError(err).Error("removing work directory")
+		}
+	}()
+
+	if err = l.unpack(workDir, zipFile); err != nil {
+		return nil, fmt.Errorf("unpacking source: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	args := append(append(append([]string{}, l.command[1:]...), commonEngineArgs...), "main.tex")
+
+	for pass := 0; pass < l.passes; pass++ {
+		cmd := exec.CommandContext(ctx, l.command[0], args...) //#nosec:G204 // Command is taken from the fixed engine list
+		cmd.Dir = workDir
+
+		output := new(bytes.Buffer)
+		cmd.Stdout = output
+		cmd.Stderr = output
+
+		if err = cmd.Run(); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("timeout of %s exceeded", l.timeout)
+			}
+
+			return nil, CompileError{
+				Log: l.readLog(workDir, output.Bytes()),
+				Err: fmt.Errorf("pass %d: %w", pass+1, err),
+			}
+		}
+	}
+
+	pdfContent, err := os.ReadFile(filepath.Join(workDir, "main.pdf")) //#nosec:G304 // Path is within our own work directory
+	if err != nil {
+		return nil, CompileError{
+			Log: l.readLog(workDir, nil),
+			Err: fmt.Errorf("reading PDF: %w", err),
+		}
+	}
+
+	return io.NopCloser(bytes.NewReader(pdfContent)), nil
+}
+
+// readLog returns the `main.log` written by the engine and falls back
+// to the given output of the engine if the log is not available
+func (LocalRenderer) readLog(workDir string, fallback []byte) []byte {
+	log, err := os.ReadFile(filepath.Join(workDir, "main.log")) //#nosec:G304 // Path is within our own work directory
+	if err != nil {
+		return fallback
+	}
+
+	return log
+}
+
+func (l LocalRenderer) unpack(workDir string, zipFile io.Reader) error {
+	// zip.Reader needs random access so we need to buffer the archive
+	content, err := io.ReadAll(zipFile)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+
+	for _, zf := range zr.File {
+		target := filepath.Join(workDir, filepath.FromSlash(zf.Name)) //#nosec:G305 // Checked below
+		if !strings.HasPrefix(target, filepath.Clean(workDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path %q in archive", zf.Name)
+		}
+
+		if zf.FileInfo().IsDir() {
+			if err = os.MkdirAll(target, 0o700); err != nil {
+				return fmt.Errorf("creating directory: %w", err)
+			}
+			continue
+		}
+
+		if err = os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+
+		if err = l.unpackFile(target, zf); err != nil {
+			return fmt.Errorf("unpacking %q: %w", zf.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (LocalRenderer) unpackFile(target string, zf *zip.File) (err error) {
+	src, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			logrus.WithError(err).Error("closing archive file")
+		}
+	}()
+
+	dst, err := os.Create(target) //#nosec:G304 // Path is checked to be within the work directory
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	defer func() {
+		if err := dst.Close(); err != nil {
+			logrus.WithError(err).Error("closing unpacked file")
+		}
+	}()
+
+	if _, err = io.Copy(dst, src); err != nil { //#nosec:G110 // Archive is generated by ourselves
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package latex
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of messages found in the LaTeX log
+const (
+	LogKindError            = "error"
+	LogKindMissingFile      = "missing-file"
+	LogKindUndefinedControl = "undefined-control-sequence"
+)
+
+const logExcerptMaxLines = 6
+
+type (
+	// LogMessage represents an error found in the LaTeX log
+	LogMessage struct {
+		// File contains the file the error occurred in (if known)
+		File string `json:"file,omitempty"`
+		// Line contains the line in the File (if known)
+		Line int `json:"line,omitempty"`
+		// TemplateLine contains the line in the `main.tex.tpl` the
+		// Line of the `main.tex` was generated from (if known)
+		TemplateLine int `json:"templateLine,omitempty"`
+
+		// Kind contains the classification of the error
+		Kind string `json:"kind"`
+		// Message contains the error message
+		Message string `json:"message"`
+		// MissingFile contains the name of the file not found for
+		// errors of kind LogKindMissingFile
+		MissingFile string `json:"missingFile,omitempty"`
+		// Excerpt contains the log lines following the error
+		Excerpt []string `json:"excerpt,omitempty"`
+	}
+)
+
+var (
+	logFileLineError = regexp.MustCompile(`^(.+?\.(?:tex|sty|cls|def)):(\d+): (.*)$`)
+	logLineNumber    = regexp.MustCompile(`^l\.(\d+)`)
+	logMissingFile   = regexp.MustCompile("File [`'\"](.+?)' not found")
+
+	// logIgnoredMessages contain messages following the real error and
+	// therefore not containing any helpful information
+	logIgnoredMessages = []string{
+		"Emergency stop.",
+		"==> Fatal error occurred, no output PDF file produced!",
+	}
+)
+
+// ParseLog extracts the errors from the log written by the TeX engine.
+// It supports logs written with and without `-file-line-error`.
+func ParseLog(log []byte) (messages []LogMessage) {
+	var (
+		current *LogMessage
+		scanner = bufio.NewScanner(bytes.NewReader(log))
+	)
+
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024) //nolint:mnd // 1MiB max line length
+
+	flush := func() {
+		if current != nil {
+			messages = append(messages, *current)
+			current = nil
+		}
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		var msg *LogMessage
+		switch {
+		case logFileLineError.MatchString(line):
+			m := logFileLineError.FindStringSubmatch(line)
+			lineNo, _ := strconv.Atoi(m[2])
+			msg = &LogMessage{File: strings.TrimPrefix(m[1], "./"), Line: lineNo, Message: m[3]}
+
+		case strings.HasPrefix(line, "! "):
+			msg = &LogMessage{Message: strings.TrimPrefix(line, "! ")}
+		}
+
+		if msg != nil {
+			flush()
+
+			if isIgnoredLogMessage(msg.Message) {
+				continue
+			}
+
+			classifyLogMessage(msg)
+			current = msg
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if len(current.Excerpt) < logExcerptMaxLines && strings.TrimSpace(line) != "" {
+			current.Excerpt = append(current.Excerpt, line)
+		}
+
+		if m := logLineNumber.FindStringSubmatch(line); m != nil {
+			if current.Line == 0 {
+				current.Line, _ = strconv.Atoi(m[1])
+			}
+			flush()
+		}
+	}
+
+	flush()
+
+	return messages
+}
+
+func classifyLogMessage(msg *LogMessage) {
+	switch {
+	case strings.Contains(msg.Message, "Undefined control sequence"):
+		msg.Kind = LogKindUndefinedControl
+
+	case logMissingFile.MatchString(msg.Message):
+		msg.Kind = LogKindMissingFile
+		msg.MissingFile = logMissingFile.FindStringSubmatch(msg.Message)[1]
+
+	default:
+		msg.Kind = LogKindError
+	}
+}
+
+func isIgnoredLogMessage(message string) bool {
+	for _, m := range logIgnoredMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapTemplateLines tries to find the line in the template the errors
+// in the generated `main.tex` originate from. As the template output
+// does not keep track of the input lines this matches the content of
+// the erroneous line against the template and picks the closest
+// matching line.
+func mapTemplateLines(messages []LogMessage, tex, tplSource []byte) []LogMessage {
+	var (
+		texLines = strings.Split(string(tex), "\n")
+		tplLines = strings.Split(string(tplSource), "\n")
+	)
+
+	for i := range messages {
+		msg := &messages[i]
+
+		if msg.Line < 1 || msg.Line > len(texLines) || (msg.File != "" && msg.File != "main.tex") {
+			continue
+		}
+
+		needle := strings.TrimSpace(texLines[msg.Line-1])
+		if needle == "" {
+			continue
+		}
+
+		bestDistance := -1
+		for j, tplLine := range tplLines {
+			if strings.TrimSpace(tplLine) != needle {
+				continue
+			}
+
+			distance := j + 1 - msg.Line
+			if distance < 0 {
+				distance = -distance
+			}
+
+			if bestDistance < 0 || distance < bestDistance {
+				bestDistance = distance
+				msg.TemplateLine = j + 1
+			}
+		}
+	}
+
+	return messages
+}
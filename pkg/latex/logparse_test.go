@@ -0,0 +1,56 @@
+package latex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLog(t *testing.T) {
+	log := []byte(`This is XeTeX, Version 3.141592653-2.6-0.999995 (TeX Live 2023) (preloaded format=xelatex 2024.1.1)
+(./main.tex
+LaTeX2e <2023-11-01> patch level 1
+./main.tex:3: Undefined control sequence.
+l.3 \foo
+        {bar}
+! LaTeX Error: File ` + "`" + `missing.sty' not found.
+
+Type X to quit or <RETURN> to proceed,
+or enter new name. (Default extension: sty)
+
+Enter file name:
+l.4 \usepackage
+               {missing}^^M
+!  ==> Fatal error occurred, no output PDF file produced!
+`)
+
+	messages := ParseLog(log)
+	assert.Equal(t, []LogMessage{
+		{
+			File:    "main.tex",
+			Line:    3,
+			Kind:    LogKindUndefinedControl,
+			Message: "Undefined control sequence.",
+			Excerpt: []string{`l.3 \foo`},
+		},
+		{
+			Line:        4,
+			Kind:        LogKindMissingFile,
+			Message:     "LaTeX Error: File `missing.sty' not found.",
+			MissingFile: "missing.sty",
+			Excerpt: []string{
+				"Type X to quit or <RETURN> to proceed,",
+				"or enter new name. (Default extension: sty)",
+				"Enter file name:",
+				`l.4 \usepackage`,
+			},
+		},
+	}, messages)
+
+	tex := []byte("\\documentclass{article}\n\\begin{document}\n\\foo{bar}\n\\end{document}\n")
+	tpl := []byte("\\documentclass{article}\n{{ if .Values.x }}\n\\begin{document}\n\\foo{bar}\n{{ end }}\n\\end{document}\n")
+
+	mapped := mapTemplateLines(messages, tex, tpl)
+	assert.Equal(t, 4, mapped[0].TemplateLine)
+	assert.Equal(t, 6, mapped[1].TemplateLine)
+}
@@ -0,0 +1,209 @@
+package latex
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/md2html"
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/invopop/jsonschema"
+)
+
+// PreviewMarkdown converts the Markdown the way the `md2tex` template
+// function of the source-set would do (when opts.SourceSet is empty
+// without the images and shortcodes of a source-set) and returns the
+// TeX together with the problems found in the document. The args are
+// the additional arguments of the `md2tex` template function.
+func PreviewMarkdown(opts RenderOpts, md string, args ...string) (tex string, diags []md2tex.Diagnostic, err error) {
+	var sourceFiles fs.FS
+	if opts.SourceSet != "" {
+		sourceFiles = sourceFS(opts)
+
+		if opts.Values, err = effectiveValues(sourceFiles, opts.Values); err != nil {
+			return "", nil, fmt.Errorf("applying defaults: %w", err)
+		}
+	}
+
+	mdOpts, sandboxed, err := markdownOptions(sourceFiles, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if sandboxed {
+		if problems := findForbiddenSequences("markdown", md); len(problems) > 0 {
+			return "", nil, ValidationError{Problems: problems}
+		}
+	}
+
+	if mdOpts, err = markdownArgs(mdOpts, args); err != nil {
+		return "", nil, err
+	}
+
+	out, diags, err := md2tex.ConvertWithDiagnostics([]byte(md), mdOpts...)
+	if err != nil {
+		return "", nil, TemplateError{Err: err}
+	}
+
+	return string(out), diags, nil
+}
+
+// PreviewHTML converts the Markdown into HTML the way PreviewMarkdown
+// converts it into TeX. Images of the source-set are referenced by the
+// URL returned from imageURL, uploaded assets are embedded as data
+// URIs. The args are the additional arguments of the `md2tex` template
+// function of which only the HTML policy is used.
+func PreviewHTML(opts RenderOpts, md string, imageURL func(path string) string, args ...string) (string, error) {
+	var htmlOpts []md2html.Option
+
+	for _, arg := range args {
+		switch {
+		case md2tex.HTMLPolicy(arg).IsValid():
+			htmlOpts = append(htmlOpts, md2html.WithHTMLPolicy(md2tex.HTMLPolicy(arg)))
+
+		case md2tex.IsSmartQuoteLanguage(arg):
+			// Quotes are not converted in HTML
+
+		default:
+			return "", fmt.Errorf("invalid argument %q (neither HTML policy nor quote language)", arg)
+		}
+	}
+
+	if opts.SourceSet != "" {
+		sourceFiles := sourceFS(opts)
+
+		var err error
+		if opts.Values, err = effectiveValues(sourceFiles, opts.Values); err != nil {
+			return "", fmt.Errorf("applying defaults: %w", err)
+		}
+
+		resolve := imageResolver(sourceFiles, opts.Assets)
+		htmlOpts = append(htmlOpts,
+			md2html.WithImageResolver(func(p string) (string, error) {
+				packed, err := resolve(p)
+				if err != nil {
+					return "", err
+				}
+
+				if name, ok := strings.CutPrefix(packed, assetDir+"/"); ok && opts.Assets[name] != nil {
+					return dataURI(name, opts.Assets[name]), nil
+				}
+
+				return imageURL(packed), nil
+			}),
+		)
+	}
+
+	out, err := md2html.Convert([]byte(md), append(htmlOpts, md2html.WithValues(opts.Values))...)
+	if err != nil {
+		return "", fmt.Errorf("converting markdown: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// markdownOptions returns the md2tex options for the render request
+// resolving images against the source-set and the uploaded assets and
+// making the shortcodes of the source-set available. If the sandbox
+// is enabled the values are checked for forbidden control sequences.
+func markdownOptions(sourceFiles fs.FS, opts RenderOpts) (mdOpts []md2tex.Option, sandboxed bool, err error) {
+	mdOpts = []md2tex.Option{md2tex.WithValues(opts.Values)}
+	sandboxed = opts.Sandbox
+
+	if sourceFiles != nil {
+		var shortcodes map[string]string
+		if shortcodes, err = readShortcodes(sourceFiles); err != nil {
+			return nil, false, TemplateError{Err: err}
+		}
+
+		mdOpts = append(mdOpts,
+			md2tex.WithImageResolver(imageResolver(sourceFiles, opts.Assets)),
+			md2tex.WithShortcodeTemplates(shortcodes),
+		)
+
+		if sandboxed, err = isSandboxed(sourceFiles, opts); err != nil {
+			return nil, false, fmt.Errorf("reading sandbox policy: %w", err)
+		}
+	}
+
+	if sandboxed {
+		if err = checkSandboxValues(opts); err != nil {
+			return nil, true, err
+		}
+		mdOpts = append(mdOpts, md2tex.WithSandbox())
+	}
+
+	return mdOpts, sandboxed, nil
+}
+
+// readMarkups returns the markups configured for the properties of
+// the schema using the `x-markup` keyword
+func readMarkups(sourceFiles fs.FS) (map[string]string, error) {
+	schema, err := readSchemaFS(sourceFiles, "schema.json")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+
+	markups := map[string]string{}
+	if err = collectMarkups(markups, "", schema); err != nil {
+		return nil, err
+	}
+
+	return markups, nil
+}
+
+// collectMarkups adds the markups of the properties of the schema to
+// the map using their path (i.e. `items.description` for the property
+// of the objects in the `items` array)
+func collectMarkups(markups map[string]string, prefix string, schema *jsonschema.Schema) error {
+	if schema.Items != nil {
+		if err := collectMarkups(markups, prefix, schema.Items); err != nil {
+			return err
+		}
+	}
+
+	if schema.Properties == nil {
+		return nil
+	}
+
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if pair.Value == nil {
+			continue
+		}
+
+		property := propertyPath(prefix, pair.Key)
+		if err := collectMarkups(markups, property, pair.Value); err != nil {
+			return err
+		}
+
+		if pair.Value.Extras["x-markup"] == nil {
+			continue
+		}
+
+		markup, ok := pair.Value.Extras["x-markup"].(string)
+		if !ok {
+			return fmt.Errorf("x-markup of property %s must be a string", property)
+		}
+		markups[property] = markup
+	}
+
+	return nil
+}
+
+// dataURI embeds the asset into a data URI using the MIME type derived
+// from its name
+func dataURI(name string, content []byte) string {
+	mimeType := mime.TypeByExtension(path.Ext(name))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(content))
+}
@@ -0,0 +1,75 @@
+package latex
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewMarkdown(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(base, "letter"), 0o700))
+	require.NoError(t, os.WriteFile(path.Join(base, "letter", "logo.png"), []byte("png"), 0o600))
+	require.NoError(t, os.WriteFile(path.Join(base, "letter", "shortcodes.json"), []byte(`{"city": "{{ .Values.city }}"}`), 0o600))
+
+	opts := RenderOpts{SourceBaseFolder: base, SourceSet: "letter", Values: map[string]any{"city": "Berlin"}}
+
+	tex, diags, err := PreviewMarkdown(opts, "{% city %}: ![](logo.png) \"x\"\n\n![](missing.png)", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin: \\includegraphics{logo.png} \\quotedblbase{}x\\textquotedblleft{}", tex)
+	assert.Equal(t, []md2tex.Diagnostic{
+		{Line: 3, Column: 1, Message: `resolving image: image "missing.png" not found in source-set or uploaded assets`},
+	}, diags)
+
+	_, _, err = PreviewMarkdown(opts, "text", "invalid")
+	assert.Error(t, err)
+
+	opts.Sandbox = true
+	_, _, err = PreviewMarkdown(opts, `\input{x}`)
+	assert.ErrorAs(t, err, &ValidationError{})
+
+	// Without source-set images are not checked for existence
+	tex, diags, err = PreviewMarkdown(RenderOpts{}, "{% city %} ![](missing.png)")
+	require.NoError(t, err)
+	assert.Contains(t, tex, `\includegraphics{missing.png}`)
+	require.Len(t, diags, 1)
+	assert.Contains(t, diags[0].Message, `function "city" not defined`)
+}
+
+func TestPreviewHTML(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(base, "letter"), 0o700))
+	require.NoError(t, os.WriteFile(path.Join(base, "letter", "logo.png"), []byte("png"), 0o600))
+
+	opts := RenderOpts{
+		Assets:           map[string][]byte{"sign.png": []byte("sign")},
+		SourceBaseFolder: base,
+		SourceSet:        "letter",
+	}
+	imageURL := func(p string) string { return "/files/" + p }
+
+	out, err := PreviewHTML(opts, "![](logo.png) ![](sign.png)", imageURL, "de")
+	require.NoError(t, err)
+	assert.Contains(t, out, `src="/files/logo.png"`)
+	assert.Contains(t, out, `src="data:image/png;base64,c2lnbg=="`)
+
+	out, err = PreviewHTML(opts, "![](missing.png)", imageURL)
+	require.NoError(t, err)
+	assert.Contains(t, out, `class="md-error"`)
+
+	_, err = PreviewHTML(opts, "text", imageURL, "invalid")
+	assert.Error(t, err)
+
+	content, err := ReadPreviewImage(base, "letter", "logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png"), content)
+
+	for _, p := range []string{"../letter/logo.png", "main.tex.tpl", "missing.png"} {
+		_, err = ReadPreviewImage(base, "letter", p)
+		assert.Error(t, err, p)
+	}
+}
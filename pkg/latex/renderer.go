@@ -0,0 +1,34 @@
+package latex
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type (
+	// Renderer takes the packed source-set (ZIP archive containing the
+	// `main.tex` and all additional files) and compiles it into a PDF
+	Renderer interface {
+		// Render compiles the archive into a PDF. The returned
+		// io.ReadCloser MUST be closed after usage to free up resources.
+		Render(ctx context.Context, zipFile io.Reader) (pdf io.ReadCloser, err error)
+	}
+
+	// CompileError is returned by a Renderer when the TeX engine was
+	// reached but failed to produce a document
+	CompileError struct {
+		// Log contains the output of the TeX engine (if available)
+		Log []byte
+		// Err contains the reason for the failure
+		Err error
+		// Messages contains the errors parsed from the Log
+		Messages []LogMessage
+	}
+)
+
+func (c CompileError) Error() string {
+	return fmt.Sprintf("compiling document: %s", c.Err)
+}
+
+func (c CompileError) Unwrap() error { return c.Err }
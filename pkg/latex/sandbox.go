@@ -0,0 +1,215 @@
+package latex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type (
+	// sandboxSettings contains the sandbox policy of the source-set
+	// defined in its schema
+	sandboxSettings struct {
+		Sandbox bool `json:"x-sandbox"`
+	}
+)
+
+var (
+	// controlSequence matches control words (including names used with
+	// the expl3 syntax like `\tex_input:D`) and control symbols
+	controlSequence = regexp.MustCompile(`\\([A-Za-z@][A-Za-z@_:]*|.?)`)
+
+	// environmentName matches the argument of `\begin` and `\end`
+	environmentName = regexp.MustCompile(`^\s*\{([^{}]*)\}`)
+
+	// sandboxEnvironments contains the environments allowed in values
+	// in sandbox mode
+	sandboxEnvironments = map[string]bool{
+		"center": true, "description": true, "enumerate": true, "flushleft": true,
+		"flushright": true, "itemize": true, "quotation": true, "quote": true,
+	}
+
+	// sandboxSequences contains the control words allowed in values in
+	// sandbox mode: text formatting, spacing and the symbols produced
+	// by texEscape. Everything else (file and shell access, changing
+	// definitions or how TeX reads its input) is rejected.
+	sandboxSequences = map[string]bool{
+		// Formatting
+		"emph": true, "textbf": true, "textit": true, "textmd": true, "textrm": true,
+		"textsc": true, "textsf": true, "textsl": true, "texttt": true, "textup": true,
+		"textsubscript": true, "textsuperscript": true, "underline": true, "sout": true,
+		"bfseries": true, "itshape": true, "mdseries": true, "normalfont": true,
+		"rmfamily": true, "scshape": true, "sffamily": true, "ttfamily": true, "upshape": true,
+		"tiny": true, "scriptsize": true, "footnotesize": true, "small": true, "normalsize": true,
+		"large": true, "Large": true, "LARGE": true, "huge": true, "Huge": true,
+		"begin": true, "end": true, "item": true, "footnote": true,
+
+		// Spacing and breaks
+		"bigskip": true, "centering": true, "hfill": true, "hspace": true, "linebreak": true,
+		"medskip": true, "newline": true, "newpage": true, "noindent": true, "pagebreak": true,
+		"par": true, "qquad": true, "quad": true, "raggedleft": true, "raggedright": true,
+		"smallskip": true, "vfill": true, "vspace": true,
+
+		// Symbols
+		"checkmark": true, "dots": true, "euro": true, "guillemotleft": true,
+		"guillemotright": true, "guilsinglleft": true, "guilsinglright": true, "LaTeX": true,
+		"ldots": true, "P": true, "quotedblbase": true, "quotesinglbase": true, "S": true,
+		"ss": true, "TeX": true, "textasciicircum": true, "textasciitilde": true,
+		"textbackslash": true, "textbar": true, "textbullet": true, "textcopyright": true,
+		"textdagger": true, "textdegree": true, "textdiv": true, "texteuro": true,
+		"textgreater": true, "textless": true, "textmu": true, "textonehalf": true,
+		"textonequarter": true, "textperthousand": true, "textpm": true, "textquotedbl": true,
+		"textquotedblleft": true, "textquotedblright": true, "textquoteleft": true,
+		"textquoteright": true, "textregistered": true, "textthreequarters": true,
+		"textthreesuperior": true, "texttimes": true, "texttrademark": true,
+		"texttwosuperior": true, "today": true,
+	}
+)
+
+// CheckSandbox rejects values and recipients containing forbidden
+// control sequences (i.e. `\input` or `\write18`) with a
+// ValidationError when the sandbox is enabled through the options or
+// the `x-sandbox` flag in the schema of the source-set
+func CheckSandbox(opts RenderOpts) error {
+	sandboxed, err := isSandboxed(sourceFS(opts), opts)
+	if err != nil {
+		return err
+	}
+
+	if !sandboxed {
+		return nil
+	}
+
+	return checkSandboxValues(opts)
+}
+
+// checkSandboxValues walks all strings of the values and recipients
+// and reports the ones containing forbidden control sequences
+func checkSandboxValues(opts RenderOpts) error {
+	problems := findForbiddenSequences("", opts.Values)
+
+	for i, r := range opts.Recipients {
+		var fields map[string]any
+
+		// The JSON representation contains all fields and extra columns
+		raw, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encoding recipient: %w", err)
+		}
+
+		if err = json.Unmarshal(raw, &fields); err != nil {
+			return fmt.Errorf("decoding recipient: %w", err)
+		}
+
+		problems = append(problems, findForbiddenSequences(fmt.Sprintf("recipients[%d]", i), fields)...)
+	}
+
+	if len(problems) > 0 {
+		return ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// forbiddenSequence returns the first control sequence (or the `^^`
+// notation which could be used to hide them) not allowed in sandbox
+// mode or an empty string if the text is safe
+func forbiddenSequence(text string) string {
+	if strings.Contains(text, "^^") {
+		return "^^"
+	}
+
+	for _, m := range controlSequence.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+
+		switch {
+		case name == "":
+			// A trailing backslash would form a control sequence with
+			// the text following the value in the template
+			return `\`
+
+		case len(name) == 1 && !unicode.IsLetter(rune(name[0])) && name != "@":
+			// Control symbols like \\, \% or \, only typeset text
+
+		case name == "begin" || name == "end":
+			env := environmentName.FindStringSubmatch(text[m[1]:])
+			if env == nil {
+				return `\` + name
+			}
+
+			if !sandboxEnvironments[env[1]] {
+				return `\` + name + "{" + env[1] + "}"
+			}
+
+		case !sandboxSequences[name]:
+			return `\` + name
+		}
+	}
+
+	return ""
+}
+
+func findForbiddenSequences(property string, value any) (problems []ValidationProblem) {
+	switch v := value.(type) {
+	case string:
+		seq := forbiddenSequence(v)
+		if seq == "" {
+			break
+		}
+
+		problems = append(problems, ValidationProblem{
+			Property: property,
+			Reason:   fmt.Sprintf("contains forbidden control sequence %s", seq),
+		})
+
+	case []any:
+		for i := range v {
+			problems = append(problems, findForbiddenSequences(fmt.Sprintf("%s[%d]", property, i), v[i])...)
+		}
+
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			key := k
+			if property != "" {
+				key = property + "." + k
+			}
+			problems = append(problems, findForbiddenSequences(key, v[k])...)
+		}
+	}
+
+	return problems
+}
+
+// isSandboxed checks whether the sandbox is enabled for the render
+// request globally or by the source-set
+func isSandboxed(sourceFiles fs.FS, opts RenderOpts) (bool, error) {
+	if opts.Sandbox {
+		return true, nil
+	}
+
+	content, err := fs.ReadFile(sourceFiles, "schema.json")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("reading schema: %w", err)
+	}
+
+	var settings sandboxSettings
+	if err = json.Unmarshal(content, &settings); err != nil {
+		return false, fmt.Errorf("parsing schema: %w", err)
+	}
+
+	return settings.Sandbox, nil
+}
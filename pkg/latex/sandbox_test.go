@@ -0,0 +1,83 @@
+package latex
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSandboxValues(t *testing.T) {
+	err := checkSandboxValues(RenderOpts{
+		Recipients: []recipientcsv.Person{{Lastname: `\immediate\write18{rm -rf /}`}},
+		Values: map[string]any{
+			"body":     `Safe text with \textbf{bold} and \inputs`,
+			"at":       `\makeatletter\@@input{/etc/passwd}`,
+			"expl":     `\ExplSyntaxOn\tex_input:D /etc/passwd`,
+			"explcs":   `\sys_shell_now:n{id}`,
+			"file":     `\begin{filecontents*}[overwrite]{x.tex}x\end{filecontents*}`,
+			"items":    []any{"ok", `\input{/etc/passwd}`},
+			"lower":    `\lowercase{x}`,
+			"nested":   map[string]any{"hidden": `^^5cinput`},
+			"new":      `\newcommand{\x}{y}`,
+			"provide":  `\providecommand{\x}{y}`,
+			"renew":    `\renewcommand{\section}{X}`,
+			"robust":   `\DeclareRobustCommand{\x}{y}`,
+			"scan":     `\scantokens{\input x}`,
+			"subject":  `\def\x{}`,
+			"trailing": `text \`,
+			"upper":    `\uppercase{x}`,
+		},
+	})
+
+	var vErr ValidationError
+	require.True(t, errors.As(err, &vErr))
+	assert.Equal(t, []ValidationProblem{
+		{Property: "at", Reason: `contains forbidden control sequence \makeatletter`},
+		{Property: "body", Reason: `contains forbidden control sequence \inputs`},
+		{Property: "expl", Reason: `contains forbidden control sequence \ExplSyntaxOn`},
+		{Property: "explcs", Reason: `contains forbidden control sequence \sys_shell_now:n`},
+		{Property: "file", Reason: `contains forbidden control sequence \begin{filecontents*}`},
+		{Property: "items[1]", Reason: `contains forbidden control sequence \input`},
+		{Property: "lower", Reason: `contains forbidden control sequence \lowercase`},
+		{Property: "nested.hidden", Reason: "contains forbidden control sequence ^^"},
+		{Property: "new", Reason: `contains forbidden control sequence \newcommand`},
+		{Property: "provide", Reason: `contains forbidden control sequence \providecommand`},
+		{Property: "renew", Reason: `contains forbidden control sequence \renewcommand`},
+		{Property: "robust", Reason: `contains forbidden control sequence \DeclareRobustCommand`},
+		{Property: "scan", Reason: `contains forbidden control sequence \scantokens`},
+		{Property: "subject", Reason: `contains forbidden control sequence \def`},
+		{Property: "trailing", Reason: `contains forbidden control sequence \`},
+		{Property: "upper", Reason: `contains forbidden control sequence \uppercase`},
+		{Property: "recipients[0].NACHNAME", Reason: `contains forbidden control sequence \immediate`},
+	}, vErr.Problems)
+
+	assert.NoError(t, checkSandboxValues(RenderOpts{Values: map[string]any{
+		"body": `\textbf{x} 100\,\% file\_name\\ \begin{itemize}\item a\end{itemize}`,
+	}}))
+}
+
+func TestSourceSetSandbox(t *testing.T) {
+	src := fstest.MapFS{
+		"main.tex.tpl": {Data: []byte(`{{ md2tex .Values.text }}`)},
+		"schema.json":  {Data: []byte(`{"x-sandbox": true, "properties": {"text": {"type": "string"}}}`)},
+	}
+
+	tpl, _, err := readTemplate(src, "main.tex.tpl")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, executeTemplate(buf, src, tpl, RenderOpts{Values: map[string]any{"text": `{% raw "\\newpage" %}`}}))
+	assert.Contains(t, buf.String(), "raw LaTeX is disabled in sandbox mode")
+
+	err = executeTemplate(new(bytes.Buffer), src, tpl, RenderOpts{Values: map[string]any{"text": `\input{x}`}})
+	assert.ErrorAs(t, err, &ValidationError{})
+
+	sandboxed, err := isSandboxed(fstest.MapFS{}, RenderOpts{})
+	require.NoError(t, err)
+	assert.False(t, sandboxed)
+}
@@ -0,0 +1,234 @@
+package latex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/Masterminds/sprig/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// serialFileNameFuncNames lists the sprig functions available in the
+// file-name pattern which is passed in by API clients and therefore
+// must neither access the environment nor allocate unbounded memory
+var serialFileNameFuncNames = []string{"default", "lower", "replace", "trunc", "upper"}
+
+// serialPrintfWidth matches widths and precisions of more than two
+// digits or taken from the arguments in printf verbs
+var serialPrintfWidth = regexp.MustCompile(`%[-+# 0]*(\*|\d{3,}|\d*\.(\*|\d{3,}))`)
+
+// DefaultSerialFileNamePattern is used to name the documents in the
+// serial-letter archive when no pattern is given
+const DefaultSerialFileNamePattern = `{{ printf "%03d" .Index }}-{{ .Recipient.Lastname }}-{{ .Recipient.Firstname }}`
+
+type (
+	// SerialOpts define how to render a serial-letter
+	SerialOpts struct {
+		// Concurrency limits how many documents are rendered in parallel
+		Concurrency int
+		// FileNamePattern is a Go template to generate the file name of
+		// each document in the archive from. It has access to the
+		// `.Index` (starting at 1) and the `.Recipient` of the document
+		// as well as the `.Values` of the render request. Besides
+		// the template builtins only `default`, `lower`, `replace`,
+		// `trunc` and `upper` are available. The `.pdf` suffix is
+		// added if not present.
+		FileNamePattern string
+		// Progress is called (if set) after each document added to the
+		// archive
+		Progress func(done, total int)
+	}
+
+	serialFileNameData struct {
+		Index     int
+		Recipient recipientcsv.Person
+		Values    any
+	}
+
+	serialResult struct {
+		pdf []byte
+		err error
+	}
+)
+
+// RenderSerial renders one document per recipient in the options
+// and writes the resulting PDFs into a ZIP archive streamed to dst.
+// The documents are added to the archive in order of the recipients.
+func RenderSerial(ctx context.Context, dst io.Writer, opts RenderOpts, serialOpts SerialOpts) (err error) {
+	if serialOpts.Concurrency < 1 {
+		serialOpts.Concurrency = 1
+	}
+
+	if serialOpts.FileNamePattern == "" {
+		serialOpts.FileNamePattern = DefaultSerialFileNamePattern
+	}
+
+	nameTpl, err := template.New("fileName").Funcs(serialFileNameFuncs()).Parse(serialOpts.FileNamePattern)
+	if err != nil {
+		return fmt.Errorf("parsing file-name pattern: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		recipients = opts.Recipients
+		results    = make([]chan serialResult, len(recipients))
+		sem        = make(chan struct{}, serialOpts.Concurrency)
+	)
+
+	for i := range results {
+		results[i] = make(chan serialResult, 1)
+	}
+
+	go func() {
+		for i := range recipients {
+			if ctx.Err() != nil {
+				results[i] <- serialResult{err: ctx.Err()}
+				continue
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] <- serialResult{err: ctx.Err()}
+				continue
+			}
+
+			go func(i int) {
+				recipientOpts := opts
+				recipientOpts.Recipients = []recipientcsv.Person{recipients[i]}
+
+				pdf, err := renderBytes(ctx, recipientOpts)
+				results[i] <- serialResult{pdf: pdf, err: err}
+			}(i)
+		}
+	}()
+
+	var (
+		usedNames = map[string]bool{}
+		zw        = zip.NewWriter(dst)
+	)
+
+	for i := range recipients {
+		res := <-results[i]
+		if res.err != nil {
+			// Results for a cancelled context did not take a slot, so
+			// we must not wait for one to be released
+			return fmt.Errorf("rendering document %d: %w", i+1, res.err)
+		}
+
+		// Release the slot only after consuming the result to limit the
+		// number of documents held in memory
+		<-sem
+
+		name, err := serialFileName(nameTpl, serialFileNameData{
+			Index:     i + 1,
+			Recipient: recipients[i],
+			Values:    opts.Values,
+		}, usedNames)
+		if err != nil {
+			return fmt.Errorf("generating file name for document %d: %w", i+1, err)
+		}
+
+		f, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("creating file in archive: %w", err)
+		}
+
+		if _, err = f.Write(res.pdf); err != nil {
+			return fmt.Errorf("writing file to archive: %w", err)
+		}
+
+		if serialOpts.Progress != nil {
+			serialOpts.Progress(i+1, len(recipients))
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("closing archive: %w", err)
+	}
+
+	return nil
+}
+
+func renderBytes(ctx context.Context, opts RenderOpts) ([]byte, error) {
+	pdf, err := Render(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := pdf.Close(); err != nil {
+			logrus.WithError(err).Error("closing PDF reader")
+		}
+	}()
+
+	content, err := io.ReadAll(pdf)
+	if err != nil {
+		return nil, fmt.Errorf("reading PDF: %w", err)
+	}
+
+	return content, nil
+}
+
+// serialFileNameFuncs returns the allow-listed functions available in
+// the file-name pattern
+func serialFileNameFuncs() template.FuncMap {
+	var (
+		fm         = template.FuncMap{"printf": serialPrintf}
+		sprigFuncs = sprig.TxtFuncMap()
+	)
+
+	for _, name := range serialFileNameFuncNames {
+		fm[name] = sprigFuncs[name]
+	}
+
+	return fm
+}
+
+// serialPrintf is the builtin printf having its widths and precisions
+// limited to prevent patterns like %0999999999d allocating memory
+func serialPrintf(format string, args ...any) (string, error) {
+	if serialPrintfWidth.MatchString(format) {
+		return "", fmt.Errorf("width or precision in format %q too large", format)
+	}
+
+	return fmt.Sprintf(format, args...), nil
+}
+
+func serialFileName(tpl *template.Template, data serialFileNameData, usedNames map[string]bool) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r < ' ', strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, strings.TrimSpace(buf.String()))
+
+	name = strings.TrimSuffix(name, ".pdf")
+	if name == "" {
+		name = fmt.Sprintf("%03d", data.Index)
+	}
+
+	// Ensure every document gets its own file in the archive
+	candidate := name
+	for n := 2; usedNames[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+	usedNames[candidate] = true
+
+	return candidate + ".pdf", nil
+}
@@ -0,0 +1,129 @@
+package latex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type texEchoRenderer struct{}
+
+// Render returns the generated main.tex instead of a PDF
+func (texEchoRenderer) Render(_ context.Context, zipFile io.Reader) (io.ReadCloser, error) {
+	content, err := io.ReadAll(zipFile)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	return zr.Open("main.tex")
+}
+
+func TestRenderSerial(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(base, "letter"), 0o700))
+	require.NoError(t, os.WriteFile(
+		path.Join(base, "letter", "main.tex.tpl"),
+		[]byte(`{{ range .Recipients }}{{ .Firstname }} {{ .Lastname }}{{ end }}`),
+		0o600,
+	))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, RenderSerial(context.Background(), buf, RenderOpts{
+		Renderer:         texEchoRenderer{},
+		SourceBaseFolder: base,
+		SourceSet:        "letter",
+		Recipients: []recipientcsv.Person{
+			{Firstname: "Karl", Lastname: "Muster"},
+			{Firstname: "Birgit", Lastname: "Muster"},
+			{Firstname: "Karl", Lastname: "Muster"},
+		},
+	}, SerialOpts{
+		Concurrency:     2,
+		FileNamePattern: "{{ .Recipient.Lastname }}/{{ .Recipient.Firstname }}",
+	}))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var (
+		names    []string
+		contents []string
+	)
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+
+	assert.Equal(t, []string{"Muster_Karl.pdf", "Muster_Birgit.pdf", "Muster_Karl-2.pdf"}, names)
+	assert.Equal(t, []string{"Karl Muster", "Birgit Muster", "Karl Muster"}, contents)
+}
+
+func TestRenderSerialCancelled(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(base, "letter"), 0o700))
+	require.NoError(t, os.WriteFile(path.Join(base, "letter", "main.tex.tpl"), []byte(`letter`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RenderSerial(ctx, io.Discard, RenderOpts{
+			Renderer:         texEchoRenderer{},
+			SourceBaseFolder: base,
+			SourceSet:        "letter",
+			Recipients:       []recipientcsv.Person{{Lastname: "A"}, {Lastname: "B"}, {Lastname: "C"}},
+		}, SerialOpts{Concurrency: 1})
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RenderSerial did not return for cancelled context")
+	}
+}
+
+func TestSerialFileNameFuncs(t *testing.T) {
+	for _, pattern := range []string{`{{ env "HOME" }}`, `{{ expandenv "$PATH" }}`, `{{ repeat 1000000000 "a" }}`} {
+		_, err := template.New("fileName").Funcs(serialFileNameFuncs()).Parse(pattern)
+		assert.Error(t, err, pattern)
+	}
+
+	tpl, err := template.New("fileName").Funcs(serialFileNameFuncs()).
+		Parse(`{{ printf "%03d" .Index }}-{{ .Recipient.Lastname | lower | trunc 3 }}-{{ .Recipient.Firstname | default "x" | upper }}`)
+	require.NoError(t, err)
+
+	name, err := serialFileName(tpl, serialFileNameData{
+		Index:     7,
+		Recipient: recipientcsv.Person{Lastname: "Muster"},
+	}, map[string]bool{})
+	require.NoError(t, err)
+	assert.Equal(t, "007-mus-X.pdf", name)
+
+	tpl, err = template.New("fileName").Funcs(serialFileNameFuncs()).Parse(`{{ printf "%0999999999d" .Index }}`)
+	require.NoError(t, err)
+
+	_, err = serialFileName(tpl, serialFileNameData{Index: 1}, map[string]bool{})
+	assert.Error(t, err)
+}
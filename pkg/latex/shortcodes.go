@@ -0,0 +1,31 @@
+package latex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// shortcodesFile contains the custom shortcodes of a source-set as
+// JSON object mapping the name of the shortcode to its Go template
+const shortcodesFile = "shortcodes.json"
+
+// readShortcodes reads the custom shortcodes of the source-set, a
+// missing file yields no custom shortcodes
+func readShortcodes(sourceFiles fs.FS) (map[string]string, error) {
+	content, err := fs.ReadFile(sourceFiles, shortcodesFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", shortcodesFile, err)
+	}
+
+	var shortcodes map[string]string
+	if err = json.Unmarshal(content, &shortcodes); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", shortcodesFile, err)
+	}
+
+	return shortcodes, nil
+}
@@ -0,0 +1,30 @@
+package latex
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceSetShortcodes(t *testing.T) {
+	src := fstest.MapFS{
+		"main.tex.tpl":    {Data: []byte(`{{ md2tex .Values.text }}`)},
+		"shortcodes.json": {Data: []byte(`{"sign": "\\signature{ {{- index .Args 0 | escape -}} }{{ .Values.city }}"}`)},
+	}
+
+	tpl, _, err := readTemplate(src, "main.tex.tpl")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, executeTemplate(buf, src, tpl, RenderOpts{Values: map[string]any{
+		"city": "Berlin",
+		"text": `Regards {% sign "J. Doe & Co" %}`,
+	}}))
+	assert.Equal(t, `Regards \signature{J. Doe \& Co}Berlin`, buf.String())
+
+	src["shortcodes.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	assert.Error(t, executeTemplate(new(bytes.Buffer), src, tpl, RenderOpts{}))
+}
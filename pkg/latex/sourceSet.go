@@ -1,13 +1,18 @@
 package latex
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
 	"github.com/invopop/jsonschema"
 	"github.com/sirupsen/logrus"
 )
@@ -25,22 +30,12 @@ func GetSourceSets(base string) (schemas map[string]jsonschema.Schema, err error
 			return nil
 		}
 
-		f, err := os.Open(filePath) //#nosec:G304 // Intended to traverse custom path
+		s, err := readSchema(filePath)
 		if err != nil {
-			return fmt.Errorf("opening schema file: %w", err)
-		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				logrus.WithError(err).Error("closing schema file")
-			}
-		}()
-
-		var s jsonschema.Schema
-		if err = json.NewDecoder(f).Decode(&s); err != nil {
-			return fmt.Errorf("parsing schema: %w", err)
+			return err
 		}
 
-		schemas[path.Base(path.Dir(filePath))] = s
+		schemas[path.Base(path.Dir(filePath))] = *s
 
 		return nil
 	}); err != nil {
@@ -50,6 +45,74 @@ func GetSourceSets(base string) (schemas map[string]jsonschema.Schema, err error
 	return schemas, nil
 }
 
+// GetSourceSet returns the definition of the given source-set
+func GetSourceSet(base, name string) (*jsonschema.Schema, error) {
+	s, err := readSchema(path.Join(base, name, "schema.json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+
+	return s, nil
+}
+
+// SourceSetHash returns a content-hash over all files (names and
+// contents) of the given source-set
+func SourceSetHash(base, name string) (string, error) {
+	var (
+		h           = sha256.New()
+		sourceFiles = os.DirFS(path.Join(base, name))
+	)
+
+	// WalkDir visits the files in lexical order so the hash is stable
+	if err := fs.WalkDir(sourceFiles, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		content, err := fs.ReadFile(sourceFiles, filePath)
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00", filePath, len(content))
+		_, _ = h.Write(content)
+
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("hashing source-set: %w", err)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// GetRecipientOptions returns the options to parse recipient CSVs
+// defined by the `recipients.json` of the source-set (or empty options
+// if the source-set does not define them)
+func GetRecipientOptions(base, name string) (opts recipientcsv.Options, err error) {
+	f, err := os.Open(path.Join(base, name, "recipients.json")) //#nosec:G304 // Intended to traverse custom path
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return opts, nil
+		}
+		return opts, fmt.Errorf("opening recipient options: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.WithError(err).Error("closing recipient options file")
+		}
+	}()
+
+	if opts, err = recipientcsv.LoadOptions(f); err != nil {
+		return opts, fmt.Errorf("loading recipient options: %w", err)
+	}
+
+	return opts, nil
+}
+
 // HasSourceSet checks whether the given directory exists and contains
 // at least the main template
 func HasSourceSet(base, name string) bool {
@@ -76,3 +139,89 @@ func HasSourceSet(base, name string) bool {
 
 	return true
 }
+
+func readSchema(filePath string) (*jsonschema.Schema, error) {
+	return readSchemaFS(os.DirFS(path.Dir(filePath)), path.Base(filePath))
+}
+
+func readSchemaFS(src fs.FS, name string) (*jsonschema.Schema, error) {
+	f, err := src.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening schema file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.WithError(err).Error("closing schema file")
+		}
+	}()
+
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+
+	var s jsonschema.Schema
+	if err = json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+
+	if err = applyExtras(&s, raw); err != nil {
+		return nil, fmt.Errorf("parsing schema extensions: %w", err)
+	}
+
+	return &s, nil
+}
+
+// applyExtras fills the Extras of the schema and its properties with
+// the custom keywords (prefixed with `x-`) as those are not read when
+// decoding the schema
+func applyExtras(s *jsonschema.Schema, raw json.RawMessage) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keywords); err != nil {
+		// Boolean schemas have no keywords
+		return nil //nolint:nilerr // Not an object, nothing to apply
+	}
+
+	for key, value := range keywords {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+
+		var v any
+		if err := json.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("decoding %s: %w", key, err)
+		}
+
+		if s.Extras == nil {
+			s.Extras = map[string]any{}
+		}
+		s.Extras[key] = v
+	}
+
+	if s.Items != nil && keywords["items"] != nil {
+		if err := applyExtras(s.Items, keywords["items"]); err != nil {
+			return err
+		}
+	}
+
+	if s.Properties == nil || keywords["properties"] == nil {
+		return nil
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(keywords["properties"], &properties); err != nil {
+		return fmt.Errorf("decoding properties: %w", err)
+	}
+
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if pair.Value == nil || properties[pair.Key] == nil {
+			continue
+		}
+
+		if err := applyExtras(pair.Value, properties[pair.Key]); err != nil {
+			return fmt.Errorf("property %s: %w", pair.Key, err)
+		}
+	}
+
+	return nil
+}
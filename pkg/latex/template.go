@@ -1,23 +1,156 @@
 package latex
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
 	"github.com/Masterminds/sprig/v3"
 )
 
-func templateFuncs() template.FuncMap {
+var (
+	arrayIndex   = regexp.MustCompile(`\[\d+\]`)
+	pathSegments = regexp.MustCompile(`[^.\[\]]+|\[\d+\]`)
+)
+
+type (
+	// templateContext contains the data of the render request the
+	// template functions depend on
+	templateContext struct {
+		// markups maps the properties to their `x-markup`
+		markups map[string]string
+		// mdOpts are passed to the Markdown conversion
+		mdOpts []md2tex.Option
+		values any
+	}
+)
+
+// templateFuncs returns the functions available in the templates
+func templateFuncs(ctx templateContext) template.FuncMap {
 	fm := make(template.FuncMap)
 
 	for fn, f := range sprig.FuncMap() {
 		fm[fn] = f
 	}
 
-	fm["md2tex"] = func(s string) (string, error) {
-		l, err := md2tex.Convert([]byte(s))
-		return string(l), err
+	for fn, f := range invoiceFuncs() {
+		fm[fn] = f
+	}
+
+	fm["formatAddress"] = formatAddress
+
+	// The converters optionally take the policy for unsupported HTML
+	// and / or the language to convert quotes for
+	fm["asciidoc2tex"] = func(s string, args ...string) (string, error) {
+		return Convert("asciidoc", s, ctx.mdOpts, args...)
+	}
+
+	fm["convert"] = func(markup, s string, args ...string) (string, error) {
+		return Convert(markup, s, ctx.mdOpts, args...)
+	}
+
+	fm["md2tex"] = func(s string, args ...string) (string, error) {
+		return Convert("markdown", s, ctx.mdOpts, args...)
+	}
+
+	fm["text2tex"] = func(s string, args ...string) (string, error) {
+		return Convert("text", s, ctx.mdOpts, args...)
+	}
+
+	// markup converts the value of the property using the markup
+	// configured in its `x-markup` keyword, nested properties are
+	// given by their path (i.e. `items[0].description`)
+	fm["markup"] = func(property string, args ...string) (string, error) {
+		markup := ctx.markups[arrayIndex.ReplaceAllString(property, "")]
+		if markup == "" {
+			markup = DefaultMarkup
+		}
+
+		var text string
+		if value := lookupValue(ctx.values, property); value != nil {
+			text = fmt.Sprint(value)
+		}
+
+		return Convert(markup, text, ctx.mdOpts, args...)
+	}
+
+	// texEscape escapes plain text optionally converting quotes for
+	// the given language
+	fm["texEscape"] = func(s string, args ...string) (string, error) {
+		opts, err := markdownArgs(nil, args)
+		if err != nil {
+			return "", err
+		}
+
+		return md2tex.Escape(s, opts...), nil
 	}
 
 	return fm
 }
+
+// markdownArgs converts the arguments given to the template functions
+// into md2tex options added to the base options
+func markdownArgs(base []md2tex.Option, args []string) ([]md2tex.Option, error) {
+	opts := append([]md2tex.Option{}, base...)
+
+	for _, arg := range args {
+		switch {
+		case md2tex.HTMLPolicy(arg).IsValid():
+			opts = append(opts, md2tex.WithHTMLPolicy(md2tex.HTMLPolicy(arg)))
+
+		case md2tex.IsSmartQuoteLanguage(arg):
+			opts = append(opts, md2tex.WithSmartQuotes(arg))
+
+		default:
+			return nil, fmt.Errorf("invalid argument %q (neither HTML policy nor quote language)", arg)
+		}
+	}
+
+	return opts, nil
+}
+
+// formatAddress renders the postal address block of the person as
+// escaped LaTeX lines separated by `\\`. An optional home-country
+// (defaults to Germany) controls whether the country line is added.
+func formatAddress(p recipientcsv.Person, homeCountry ...string) string {
+	var home string
+	if len(homeCountry) > 0 {
+		home = homeCountry[0]
+	}
+
+	lines := p.AddressLines(home)
+	for i := range lines {
+		lines[i] = md2tex.Escape(lines[i])
+	}
+
+	return strings.Join(lines, "\\\\\n")
+}
+
+// lookupValue returns the value at the property path (i.e.
+// `items[0].description`) or nil if the path does not exist
+func lookupValue(values any, property string) any {
+	value := values
+
+	for _, segment := range pathSegments.FindAllString(property, -1) {
+		switch v := value.(type) {
+		case []any:
+			idx, err := strconv.Atoi(strings.Trim(segment, "[]"))
+			if err != nil || !strings.HasPrefix(segment, "[") || idx >= len(v) {
+				return nil
+			}
+			value = v[idx]
+
+		case map[string]any:
+			value = v[segment]
+
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
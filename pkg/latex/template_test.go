@@ -0,0 +1,36 @@
+package latex
+
+import (
+	"testing"
+
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAddress(t *testing.T) {
+	p := recipientcsv.Person{
+		Company:      "Muster & Söhne",
+		Firstname:    "Karl",
+		Lastname:     "Muster",
+		Street:       "Musterstraße",
+		StreetNumber: "12",
+		PostalCode:   "12345",
+		City:         "Musterhausen",
+		Country:      "Germany",
+	}
+
+	assert.Equal(t, "Muster \\& Söhne\\\\\nKarl Muster\\\\\nMusterstraße 12\\\\\n12345 Musterhausen", formatAddress(p))
+	assert.Equal(t, "Muster \\& Söhne\\\\\nKarl Muster\\\\\nMusterstraße 12\\\\\n12345 Musterhausen\\\\\nGERMANY", formatAddress(p, "FR"))
+}
+
+func TestTexEscape(t *testing.T) {
+	texEscape := templateFuncs(templateContext{})["texEscape"].(func(string, ...string) (string, error))
+
+	out, err := texEscape(`"Müller & Söhne" – 100 %`, "de")
+	require.NoError(t, err)
+	assert.Equal(t, `\quotedblbase{}Müller \& Söhne\textquotedblleft{} -- 100 \%`, out)
+
+	_, err = texEscape("foo", "klingon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package latex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type (
+	// TexAPIRenderer implements the Renderer interface by uploading the
+	// archive to the `/job` endpoint of a TeX-API instance
+	TexAPIRenderer struct {
+		url string
+	}
+)
+
+var _ Renderer = TexAPIRenderer{}
+
+// NewTexAPIRenderer creates a new TexAPIRenderer for the given URL
+// of the TeX-API `/job` endpoint
+func NewTexAPIRenderer(url string) TexAPIRenderer {
+	return TexAPIRenderer{url: url}
+}
+
+// Render uploads the archive to the TeX-API and returns the PDF
+// returned by the API
+func (t TexAPIRenderer) Render(ctx context.Context, zipFile io.Reader) (pdf io.ReadCloser, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, zipFile)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/pdf")
+	req.Header.Set("Content-Type", "application/zip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte(fmt.Sprintf("reading body: %s", err))
+		}
+		_ = resp.Body.Close()
+		return nil, CompileError{
+			Log: body,
+			Err: fmt.Errorf("unexpected status: %d", resp.StatusCode),
+		}
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,275 @@
+package latex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/invopop/jsonschema"
+)
+
+type (
+	// ValidationError is returned when the values do not match the
+	// schema of the source-set
+	ValidationError struct {
+		Problems []ValidationProblem
+	}
+
+	// ValidationProblem describes a single property failing the
+	// validation
+	ValidationProblem struct {
+		Property string `json:"property"`
+		Reason   string `json:"reason"`
+	}
+)
+
+func (v ValidationError) Error() string {
+	var parts []string
+	for _, p := range v.Problems {
+		parts = append(parts, fmt.Sprintf("%s: %s", p.Property, p.Reason))
+	}
+
+	return fmt.Sprintf("invalid values: %s", strings.Join(parts, ", "))
+}
+
+// ValidateValues checks the given values against the schema of the
+// source-set and returns a ValidationError listing all failing
+// properties if the values do not match. Nested objects and arrays are
+// checked recursively, their problems are reported using the path of
+// the property (i.e. `items[0].price`).
+func ValidateValues(schema *jsonschema.Schema, values map[string]any) error {
+	problems := validateObject("", schema, values)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Property < problems[j].Property })
+	return ValidationError{Problems: problems}
+}
+
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func isFalseSchema(s *jsonschema.Schema) bool {
+	if s == nil {
+		return false
+	}
+
+	raw, err := json.Marshal(s)
+	return err == nil && string(raw) == "false"
+}
+
+func propertyPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// validateObject checks the properties of the object against the
+// schema, the prefix is prepended to the reported properties
+func validateObject(prefix string, schema *jsonschema.Schema, values map[string]any) (problems []ValidationProblem) {
+	if schema.Properties != nil {
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			value, ok := values[pair.Key]
+			if !ok || value == nil {
+				continue
+			}
+
+			problems = append(problems, validateProperty(propertyPath(prefix, pair.Key), pair.Value, value)...)
+		}
+	}
+
+	for _, name := range schema.Required {
+		if isEmptyValue(values[name]) {
+			problems = append(problems, ValidationProblem{Property: propertyPath(prefix, name), Reason: "value is required"})
+		}
+	}
+
+	if isFalseSchema(schema.AdditionalProperties) {
+		for name := range values {
+			if schema.Properties == nil {
+				problems = append(problems, ValidationProblem{Property: propertyPath(prefix, name), Reason: "property is not allowed"})
+				continue
+			}
+
+			if _, ok := schema.Properties.Get(name); !ok {
+				problems = append(problems, ValidationProblem{Property: propertyPath(prefix, name), Reason: "property is not allowed"})
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateProperty checks the value of the property and descends into
+// the properties of objects and the items of arrays
+func validateProperty(property string, schema *jsonschema.Schema, value any) (problems []ValidationProblem) {
+	for _, reason := range validateValue(schema, value) {
+		problems = append(problems, ValidationProblem{Property: property, Reason: reason})
+	}
+
+	if schema == nil {
+		return problems
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		problems = append(problems, validateObject(property, schema, v)...)
+
+	case []any:
+		if schema.Items == nil {
+			break
+		}
+
+		for i, item := range v {
+			if item == nil {
+				continue
+			}
+
+			problems = append(problems, validateProperty(fmt.Sprintf("%s[%d]", property, i), schema.Items, item)...)
+		}
+	}
+
+	return problems
+}
+
+//nolint:gocognit,gocyclo // Simple checks, splitting makes it harder to read
+func validateValue(schema *jsonschema.Schema, value any) (reasons []string) {
+	if schema == nil {
+		return nil
+	}
+
+	switch schema.Type {
+	case "", "null":
+		// No type given or explicitly null, nothing to check
+
+	case "array":
+		if _, ok := value.([]any); !ok {
+			return []string{"value must be an array"}
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{"value must be a boolean"}
+		}
+
+	case "integer":
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return []string{"value must be an integer"}
+		}
+
+	case "number":
+		if _, ok := toFloat(value); !ok {
+			return []string{"value must be a number"}
+		}
+
+	case "object":
+		if _, ok := value.(map[string]any); !ok {
+			return []string{"value must be an object"}
+		}
+
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []string{"value must be a string"}
+		}
+
+	default:
+		return []string{fmt.Sprintf("unsupported type %q in schema", schema.Type)}
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool { return reflect.DeepEqual(e, value) }) {
+		reasons = append(reasons, fmt.Sprintf("value must be one of %v", schema.Enum))
+	}
+
+	if s, ok := value.(string); ok {
+		l := uint64(utf8.RuneCountInString(s))
+		if schema.MinLength != nil && l < *schema.MinLength {
+			reasons = append(reasons, fmt.Sprintf("value must have at least %d characters", *schema.MinLength))
+		}
+		if schema.MaxLength != nil && l > *schema.MaxLength {
+			reasons = append(reasons, fmt.Sprintf("value must have at most %d characters", *schema.MaxLength))
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			switch {
+			case err != nil:
+				reasons = append(reasons, fmt.Sprintf("invalid pattern in schema: %s", err))
+			case !re.MatchString(s):
+				reasons = append(reasons, fmt.Sprintf("value must match pattern %q", schema.Pattern))
+			}
+		}
+	}
+
+	if items, ok := value.([]any); ok {
+		l := uint64(len(items))
+		if schema.MinItems != nil && l < *schema.MinItems {
+			reasons = append(reasons, fmt.Sprintf("value must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && l > *schema.MaxItems {
+			reasons = append(reasons, fmt.Sprintf("value must have at most %d items", *schema.MaxItems))
+		}
+	}
+
+	if f, ok := toFloat(value); ok {
+		for _, limit := range []struct {
+			bound  json.Number
+			failed func(bound float64) bool
+			reason string
+		}{
+			{schema.Minimum, func(b float64) bool { return f < b }, "value must be at least %s"},
+			{schema.Maximum, func(b float64) bool { return f > b }, "value must be at most %s"},
+			{schema.ExclusiveMinimum, func(b float64) bool { return f <= b }, "value must be greater than %s"},
+			{schema.ExclusiveMaximum, func(b float64) bool { return f >= b }, "value must be less than %s"},
+		} {
+			if limit.bound == "" {
+				continue
+			}
+
+			b, err := limit.bound.Float64()
+			if err != nil {
+				reasons = append(reasons, fmt.Sprintf("invalid limit %q in schema", limit.bound))
+				continue
+			}
+
+			if limit.failed(b) {
+				reasons = append(reasons, fmt.Sprintf(limit.reason, limit.bound))
+			}
+		}
+	}
+
+	return reasons
+}
@@ -0,0 +1,101 @@
+package latex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateValues(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer", "minimum": 1},
+			"kind": {"type": "string", "enum": ["a", "b"]},
+			"subject": {"type": "string", "maxLength": 5}
+		},
+		"additionalProperties": false,
+		"required": ["subject"]
+	}`), &schema))
+
+	assert.NoError(t, ValidateValues(&schema, map[string]any{
+		"count":   float64(3),
+		"kind":    "a",
+		"subject": "Hello",
+	}))
+
+	err := ValidateValues(&schema, map[string]any{
+		"count":   1.5,
+		"kind":    "c",
+		"subject": "",
+		"unknown": true,
+	})
+
+	var vErr ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []ValidationProblem{
+		{Property: "count", Reason: "value must be an integer"},
+		{Property: "kind", Reason: "value must be one of [a b]"},
+		{Property: "subject", Reason: "value is required"},
+		{Property: "unknown", Reason: "property is not allowed"},
+	}, vErr.Problems)
+}
+
+func TestValidateNestedValues(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"customer": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}},
+				"required": ["name"]
+			},
+			"items": {
+				"type": "array",
+				"minItems": 1,
+				"items": {
+					"type": "object",
+					"properties": {
+						"description": {"type": "string"},
+						"quantity": {"type": "number", "minimum": 1}
+					},
+					"additionalProperties": false,
+					"required": ["description"]
+				}
+			}
+		}
+	}`), &schema))
+
+	assert.NoError(t, ValidateValues(&schema, map[string]any{
+		"customer": map[string]any{"name": "Jane"},
+		"items":    []any{map[string]any{"description": "Consulting", "quantity": float64(2)}},
+	}))
+
+	err := ValidateValues(&schema, map[string]any{
+		"customer": map[string]any{},
+		"items": []any{
+			map[string]any{"description": "Consulting", "quantity": float64(0)},
+			map[string]any{"price": float64(3)},
+			"text",
+		},
+	})
+
+	var vErr ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []ValidationProblem{
+		{Property: "customer.name", Reason: "value is required"},
+		{Property: "items[0].quantity", Reason: "value must be at least 1"},
+		{Property: "items[1].description", Reason: "value is required"},
+		{Property: "items[1].price", Reason: "property is not allowed"},
+		{Property: "items[2]", Reason: "value must be an object"},
+	}, vErr.Problems)
+
+	err = ValidateValues(&schema, map[string]any{"items": []any{}})
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []ValidationProblem{{Property: "items", Reason: "value must have at least 1 items"}}, vErr.Problems)
+}
@@ -0,0 +1,47 @@
+// Package md2html converts Markdown documents into HTML with the same
+// semantics as the md2tex package in order to preview them
+package md2html
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// prio must be lower than the priority of the HTML renderer for our
+// renderer to take precedence
+const prio = 100
+
+// Convert takes a Markdown document and returns HTML from it. The
+// same extensions as in md2tex are supported. Shortcodes are rendered
+// as visible placeholders (`vspace` as spacing, `graphic` as image,
+// `part` as title) and raw HTML is restricted to the subset md2tex
+// supports.
+func Convert(md []byte, opts ...Option) (html []byte, err error) {
+	md, calls := mdcommon.ExtractShortcodes(md)
+
+	g := &generator{config: newConfig(opts), calls: calls}
+
+	gm := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.Strikethrough,
+			extension.TaskList,
+			extension.Footnote,
+		),
+		goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(g, prio))),
+	)
+
+	output := new(bytes.Buffer)
+	if err = gm.Convert(md, output); err != nil {
+		return nil, fmt.Errorf("rendering HTML: %w", err)
+	}
+
+	// Shortcodes outside of text (i.e. in code) are shown unchanged
+	return bytes.TrimSpace(g.restoreShortcodes(output.Bytes())), nil
+}
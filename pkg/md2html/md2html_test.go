@@ -0,0 +1,44 @@
+package md2html
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	md := "# Title\n\n" +
+		"Text *with* {% vspace 1cm %} and {% part \"A & B\" %} or {% raw `\\newpage` %} {% custom x %}\n\n" +
+		"{% graphic logo.png 50% %} ![Alt](logo.png#width=0.5\\linewidth \"Logo\") ![x](missing.png)\n\n" +
+		"`{% vspace 1cm %}` <b class=\"x\">bold</b> <script>alert(1)</script> {% vspace .Values.space %}\n"
+
+	html, err := Convert([]byte(md),
+		WithImageResolver(func(p string) (string, error) {
+			if p != "logo.png" {
+				return "", errors.New("not found")
+			}
+			return "/api/sets/letter/files/" + p, nil
+		}),
+		WithValues(map[string]any{"space": "2em"}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, `<h1>Title</h1>
+<p>Text <em>with</em> <span class="md-vspace" style="display: block; height: 1cm"></span> and <span class="md-part">A &amp; B</span> or <code class="md-raw">\newpage</code> <span class="md-shortcode">{% custom x %}</span></p>
+<p><img src="/api/sets/letter/files/logo.png" alt="" style="width: 50%"> <img src="/api/sets/letter/files/logo.png" alt="Alt" title="Logo" style="width: 50%"> <span class="md-error" title="not found">x</span></p>
+<p><code>{% vspace 1cm %}</code> <b>bold</b> alert(1) <span class="md-vspace" style="display: block; height: 2em"></span></p>`, string(html))
+}
+
+func TestConvertHTMLPolicy(t *testing.T) {
+	html, err := Convert([]byte("a <span>b</span>"), WithHTMLPolicy(md2tex.HTMLEscape))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>a &lt;span&gt;b&lt;/span&gt;</p>", string(html))
+
+	html, err = Convert([]byte("{% vspace 1cm}x %} ![](/etc/passwd)"))
+	require.NoError(t, err)
+	assert.Equal(t, `<p><span class="md-error" title="vspace takes exactly one dimension">{% vspace 1cm}x %}</span> `+
+		`<span class="md-error" title="image path &#34;/etc/passwd&#34; must be relative to the document"></span></p>`, string(html))
+}
@@ -0,0 +1,54 @@
+package md2html
+
+import (
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+)
+
+type (
+	// ImageResolver checks the path of an image referenced in the
+	// Markdown document and returns the URL to display it from or an
+	// error if the image is not available
+	ImageResolver func(path string) (string, error)
+
+	// Option configures the conversion
+	Option func(*config)
+
+	config struct {
+		htmlPolicy    md2tex.HTMLPolicy
+		imageResolver ImageResolver
+		values        any
+	}
+)
+
+// WithHTMLPolicy configures how to handle HTML not being part of the
+// supported subset, defaults to md2tex.HTMLStrip. Using
+// md2tex.HTMLError the HTML is shown as highlighted text.
+func WithHTMLPolicy(p md2tex.HTMLPolicy) Option {
+	return func(c *config) { c.htmlPolicy = p }
+}
+
+// WithImageResolver configures how to resolve the paths of images
+// referenced in the document into URLs. By default all relative paths
+// accepted by md2tex.CleanImagePath are used as URL.
+func WithImageResolver(r ImageResolver) Option {
+	return func(c *config) { c.imageResolver = r }
+}
+
+// WithValues makes the given values available to shortcodes referring
+// to them, i.e. `{% vspace .Values.spacing %}`
+func WithValues(values any) Option {
+	return func(c *config) { c.values = values }
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		htmlPolicy:    md2tex.HTMLStrip,
+		imageResolver: ImageResolver(md2tex.CleanImagePath),
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
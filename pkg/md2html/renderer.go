@@ -0,0 +1,237 @@
+package md2html
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+	"github.com/Luzifer/doc-render/pkg/md2tex"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	gmHTML "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type (
+	generator struct {
+		config config
+
+		// calls contains the shortcode calls replaced by placeholders
+		calls mdcommon.Shortcodes
+	}
+)
+
+var _ renderer.NodeRenderer = &generator{}
+
+var (
+	// cssDimension matches the dimensions md2tex accepts which are
+	// valid in CSS as well
+	cssDimension = regexp.MustCompile(`^-?\d*\.?\d+(?:cm|mm|in|pt|em|ex|%)$`)
+	// lineWidthDimension matches dimensions relative to the line width
+	lineWidthDimension = regexp.MustCompile(`^(\d*\.?\d+)\\(?:linewidth|textwidth)$`)
+
+	// htmlInlineTags contains the tags supported by md2tex
+	htmlInlineTags = map[string]bool{
+		"b": true, "br": true, "em": true, "i": true, "strong": true, "sub": true, "sup": true, "u": true,
+	}
+)
+
+func (g generator) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, g.renderHTMLBlock)
+	reg.Register(ast.KindImage, g.renderImage)
+	reg.Register(ast.KindRawHTML, g.renderRawHTML)
+	reg.Register(ast.KindText, g.renderText)
+}
+
+func (g generator) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.HTMLBlock)
+	if n.HTMLBlockType == ast.HTMLBlockType2 {
+		// Comments never produce output
+		return ast.WalkSkipChildren, nil
+	}
+
+	content := new(bytes.Buffer)
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		content.Write(line.Value(source))
+	}
+	if n.HasClosure() {
+		content.Write(n.ClosureLine.Value(source))
+	}
+
+	if out := g.unsupportedHTML(bytes.TrimSpace(content.Bytes())); out != "" {
+		if _, err := fmt.Fprintf(w, "<p>%s</p>\n", out); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing HTML: %w", err)
+		}
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (g generator) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var (
+		n         = node.(*ast.Image)
+		alt       = mdcommon.PlainText(n, source)
+		dest, opt = imageOptions(string(n.Destination))
+	)
+
+	src, err := g.config.imageResolver(dest)
+	if err != nil {
+		if _, err = fmt.Fprintf(w, `<span class="md-error" title="%s">%s</span>`, html.EscapeString(err.Error()), html.EscapeString(string(alt))); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing HTML: %w", err)
+		}
+		return ast.WalkSkipChildren, nil
+	}
+
+	if _, err = w.WriteString(imageTag(src, string(alt), string(n.Title), opt)); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing HTML: %w", err)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (g generator) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var (
+		n   = node.(*ast.RawHTML)
+		raw = new(bytes.Buffer)
+	)
+
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		raw.Write(segment.Value(source))
+	}
+
+	out, ok := inlineTag(raw.String())
+	if !ok {
+		out = g.unsupportedHTML(raw.Bytes())
+	}
+
+	if _, err := w.WriteString(out); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing HTML: %w", err)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (g generator) renderText(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Text)
+	if n.IsRaw() {
+		gmHTML.DefaultWriter.RawWrite(w, n.Segment.Value(source))
+		return ast.WalkContinue, nil
+	}
+
+	g.renderShortcodes(w, n.Segment.Value(source))
+
+	switch {
+	case n.HardLineBreak():
+		_, _ = w.WriteString("<br>\n")
+	case n.SoftLineBreak():
+		_ = w.WriteByte('\n')
+	}
+
+	return ast.WalkContinue, nil
+}
+
+// unsupportedHTML returns the HTML not being part of the supported
+// subset according to the HTML policy
+func (g generator) unsupportedHTML(raw []byte) string {
+	switch g.config.htmlPolicy {
+	case md2tex.HTMLEscape:
+		return html.EscapeString(string(raw))
+
+	case md2tex.HTMLError:
+		return fmt.Sprintf(`<span class="md-error" title="unsupported HTML">%s</span>`, html.EscapeString(string(raw)))
+
+	default:
+		return ""
+	}
+}
+
+// cssWidth converts the width given to md2tex into a CSS width
+func cssWidth(value string) (string, bool) {
+	if m := lineWidthDimension.FindStringSubmatch(value); m != nil {
+		f, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatFloat(f*100, 'f', -1, 64) + "%", true //nolint:mnd // Percent
+	}
+
+	return value, cssDimension.MatchString(value)
+}
+
+func imageTag(src, alt, title string, width string) string {
+	attrs := []string{
+		fmt.Sprintf(`src="%s"`, html.EscapeString(src)),
+		fmt.Sprintf(`alt="%s"`, html.EscapeString(alt)),
+	}
+
+	if title != "" {
+		attrs = append(attrs, fmt.Sprintf(`title="%s"`, html.EscapeString(title)))
+	}
+
+	if width != "" {
+		attrs = append(attrs, fmt.Sprintf(`style="width: %s"`, width))
+	}
+
+	return fmt.Sprintf("<img %s>", strings.Join(attrs, " "))
+}
+
+// imageOptions splits the width given in the fragment of the image
+// destination (i.e. `image.png#width=50%`) from the path
+func imageOptions(dest string) (string, string) {
+	dest, value := mdcommon.ImageWidth(dest)
+
+	if width, ok := cssWidth(value); ok {
+		return dest, width
+	}
+
+	return dest, ""
+}
+
+// inlineTag returns the tag without attributes if it is part of the
+// subset supported by md2tex
+func inlineTag(raw string) (string, bool) {
+	if mdcommon.IsHTMLComment(raw) {
+		// Comments never produce output
+		return "", true
+	}
+
+	tag, ok := mdcommon.ParseHTMLTag(raw)
+	switch {
+	case !ok || !htmlInlineTags[tag.Name]:
+		return "", false
+
+	case tag.Name == "br":
+		return "<br>", !tag.Closing
+
+	case tag.SelfClosing:
+		return "", false
+
+	case tag.Closing:
+		return fmt.Sprintf("</%s>", tag.Name), true
+
+	default:
+		return fmt.Sprintf("<%s>", tag.Name), true
+	}
+}
@@ -0,0 +1,109 @@
+package md2html
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+	gmHTML "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// renderShortcodes writes the text replacing the contained shortcode
+// placeholders by their HTML representation
+func (g generator) renderShortcodes(w util.BufWriter, value []byte) {
+	g.calls.Render(value, func(text []byte) {
+		gmHTML.DefaultWriter.Write(w, text)
+	}, func(sc mdcommon.Shortcode) {
+		out, err := g.renderShortCode(sc.Call)
+		if err != nil {
+			out = fmt.Sprintf(`<span class="md-error" title="%s">%s</span>`, html.EscapeString(err.Error()), html.EscapeString(sc.Call))
+		}
+		_, _ = w.WriteString(out)
+	})
+}
+
+// renderShortCode returns the HTML representation of the shortcode
+// call (including the `{%` and `%}` delimiters)
+func (g generator) renderShortCode(call string) (string, error) {
+	content := mdcommon.ShortcodeContent(call)
+
+	if strings.ContainsAny(content, "|()") {
+		// Template logic cannot be previewed
+		return g.placeholder(call), nil
+	}
+
+	name, rawArgs := mdcommon.SplitArgs(content)
+	if name == "" {
+		return g.placeholder(call), nil
+	}
+
+	args := make([]string, 0, len(rawArgs))
+	for _, a := range rawArgs {
+		arg, err := mdcommon.ResolveArg(a, g.config.values)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+
+	switch name {
+	case "graphic":
+		return g.shortCodeGraphic(args)
+
+	case "part":
+		if len(args) != 1 {
+			return "", errors.New("part takes exactly one argument")
+		}
+		return fmt.Sprintf(`<span class="md-part">%s</span>`, html.EscapeString(args[0])), nil
+
+	case "raw":
+		if len(args) != 1 {
+			return "", errors.New("raw takes exactly one argument")
+		}
+		return fmt.Sprintf(`<code class="md-raw">%s</code>`, html.EscapeString(args[0])), nil
+
+	case "vspace":
+		if len(args) != 1 || !mdcommon.SpaceDimension.MatchString(args[0]) {
+			return "", errors.New("vspace takes exactly one dimension")
+		}
+		return fmt.Sprintf(`<span class="md-vspace" style="display: block; height: %s"></span>`, args[0]), nil
+
+	default:
+		return g.placeholder(call), nil
+	}
+}
+
+func (g generator) shortCodeGraphic(args []string) (string, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return "", errors.New("graphic takes a path and an optional width")
+	}
+
+	dest, width := imageOptions(args[0])
+	if len(args) > 1 {
+		var ok bool
+		if width, ok = cssWidth(args[1]); !ok {
+			width = ""
+		}
+	}
+
+	src, err := g.config.imageResolver(dest)
+	if err != nil {
+		return "", fmt.Errorf("resolving image: %w", err)
+	}
+
+	return imageTag(src, "", "", width), nil
+}
+
+// placeholder shows shortcodes which cannot be previewed
+func (generator) placeholder(call string) string {
+	return fmt.Sprintf(`<span class="md-shortcode">%s</span>`, html.EscapeString(call))
+}
+
+// restoreShortcodes replaces the shortcode placeholders by the original
+// shortcode calls for contexts not rendering shortcodes (i.e. code)
+func (g generator) restoreShortcodes(value []byte) []byte {
+	return g.calls.Restore(value, html.EscapeString)
+}
@@ -0,0 +1,106 @@
+package md2tex
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+	"github.com/yuin/goldmark/ast"
+)
+
+type (
+	// Diagnostic describes a problem found while converting the
+	// document. Line and column (counted in characters) start at 1.
+	Diagnostic struct {
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Message string `json:"message"`
+	}
+
+	diagnostics struct {
+		// source contains the document before the shortcodes were
+		// replaced by placeholders
+		source []byte
+		calls  mdcommon.Shortcodes
+
+		list []Diagnostic
+	}
+)
+
+// add records the error at the given offset of the original document,
+// nil receivers (not collecting diagnostics) are ignored
+func (d *diagnostics) add(offset int, err error) {
+	if d == nil {
+		return
+	}
+
+	offset = min(max(offset, 0), len(d.source))
+
+	lineStart := bytes.LastIndexByte(d.source[:offset], '\n') + 1
+	d.list = append(d.list, Diagnostic{
+		Line:    bytes.Count(d.source[:offset], []byte{'\n'}) + 1,
+		Column:  utf8.RuneCount(d.source[lineStart:offset]) + 1,
+		Message: err.Error(),
+	})
+}
+
+// originalOffset maps an offset of the document passed to the parser
+// back to the original document containing the shortcodes
+func (d *diagnostics) originalOffset(offset int) int {
+	delta := 0
+	for _, sc := range d.calls {
+		if sc.PlaceholderEnd > offset {
+			break
+		}
+		delta = sc.Offset + len(sc.Call) - sc.PlaceholderEnd
+	}
+
+	return offset + delta
+}
+
+// fail records the error as diagnostic for the node and continues the
+// conversion when collecting diagnostics or returns the error to stop
+// the conversion otherwise
+func (g generator) fail(node ast.Node, err error) error {
+	if g.diagnostics == nil {
+		return err
+	}
+
+	g.diagnostics.add(g.diagnostics.originalOffset(nodeOffset(node)), err)
+	return nil
+}
+
+// nodeOffset returns the offset of the node in the source, using the
+// position of its first content, the end of the text before it or
+// the position of its parent when the node itself has none
+func nodeOffset(node ast.Node) int {
+	for n := node; n != nil; n = n.Parent() {
+		switch tn := n.(type) {
+		case *ast.Text:
+			return tn.Segment.Start
+
+		case *ast.RawHTML:
+			if tn.Segments.Len() > 0 {
+				return tn.Segments.At(0).Start
+			}
+		}
+
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			return n.Lines().At(0).Start
+		}
+
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*ast.Text); ok {
+				return t.Segment.Start
+			}
+		}
+
+		if t, ok := n.PreviousSibling().(*ast.Text); ok {
+			// Inline nodes without content (i.e. images without alt text)
+			// start where the text before them ends
+			return t.Segment.Stop
+		}
+	}
+
+	return 0
+}
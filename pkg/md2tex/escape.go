@@ -0,0 +1,355 @@
+package md2tex
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	// quoteStyle contains the opening and closing double and single
+	// quotes of a language
+	quoteStyle struct {
+		doubleOpen, doubleClose string
+		singleOpen, singleClose string
+	}
+)
+
+const (
+	// apostrophe is used for single quotes within words in all languages
+	apostrophe = `\textquoteright{}`
+	// emojiVariation selects the emoji variant of the preceding symbol
+	// (i.e. the heart in a red heart emoji)
+	emojiVariation = '\ufe0f'
+)
+
+// quoteStyles contains the quote styles available for smart-quote
+// conversion by language
+var quoteStyles = map[string]quoteStyle{
+	"de": {`\quotedblbase{}`, `\textquotedblleft{}`, `\quotesinglbase{}`, `\textquoteleft{}`},
+	"en": {`\textquotedblleft{}`, `\textquotedblright{}`, `\textquoteleft{}`, `\textquoteright{}`},
+	"fr": {`\guillemotleft{}\,`, `\,\guillemotright{}`, `\guilsinglleft{}\,`, `\,\guilsinglright{}`},
+}
+
+// emojiSymbols contains the symbols below the emoji blocks being
+// displayed as emoji by default (Emoji_Presentation property)
+var emojiSymbols = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x23f0, Hi: 0x23f3, Stride: 3},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x267f, Hi: 0x2693, Stride: 20},
+		{Lo: 0x26a1, Hi: 0x26a1, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x26ce, Hi: 0x26d4, Stride: 6},
+		{Lo: 0x26ea, Hi: 0x26ea, Stride: 1},
+		{Lo: 0x26f2, Hi: 0x26f3, Stride: 1},
+		{Lo: 0x26f5, Hi: 0x26fa, Stride: 5},
+		{Lo: 0x26fd, Hi: 0x2705, Stride: 8},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274e, Stride: 2},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27bf, Stride: 15},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b55, Stride: 5},
+	},
+}
+
+// textReplacements maps the characters having a special meaning in
+// LaTeX and typographic characters to their LaTeX representation
+var textReplacements = map[rune]string{
+	// LaTeX specials
+	'\\': `\textbackslash{}`,
+	'~':  `\textasciitilde{}`,
+	'^':  `\textasciicircum{}`,
+	'&':  `\&`,
+	'%':  `\%`,
+	'$':  `\$`,
+	'#':  `\#`,
+	'_':  `\_`,
+	'{':  `\{`,
+	'}':  `\}`,
+	'<':  `\textless{}`,
+	'>':  `\textgreater{}`,
+	'|':  `\textbar{}`,
+	'"':  `\textquotedbl{}`,
+
+	// Quotes
+	'„': `\quotedblbase{}`,
+	'‚': `\quotesinglbase{}`,
+	'“': `\textquotedblleft{}`,
+	'”': `\textquotedblright{}`,
+	'‘': `\textquoteleft{}`,
+	'’': `\textquoteright{}`,
+	'«': `\guillemotleft{}`,
+	'»': `\guillemotright{}`,
+	'‹': `\guilsinglleft{}`,
+	'›': `\guilsinglright{}`,
+
+	// Dashes, spaces and punctuation
+	'–':      `--`,
+	'—':      `---`,
+	'…':      `\dots{}`,
+	'•':      `\textbullet{}`,
+	'\u00a0': `~`,
+	'\u202f': `\,`,
+	'\u2009': `\,`,
+	'\u00ad': `\-`,
+	'\u200b': ``,
+
+	// Symbols
+	'€': `\texteuro{}`,
+	'§': `\S{}`,
+	'¶': `\P{}`,
+	'©': `\textcopyright{}`,
+	'®': `\textregistered{}`,
+	'™': `\texttrademark{}`,
+	'°': `\textdegree{}`,
+	'×': `\texttimes{}`,
+	'÷': `\textdiv{}`,
+	'±': `\textpm{}`,
+	'µ': `\textmu{}`,
+	'²': `\texttwosuperior{}`,
+	'³': `\textthreesuperior{}`,
+	'½': `\textonehalf{}`,
+	'¼': `\textonequarter{}`,
+	'¾': `\textthreequarters{}`,
+	'†': `\textdagger{}`,
+	'‰': `\textperthousand{}`,
+
+	// Check marks and boxes (using the amssymb package like task lists)
+	'✓': `$\checkmark$`,
+	'✔': `$\checkmark$`,
+	'✗': `$\times$`,
+	'✘': `$\times$`,
+	'☐': `$\square$`,
+	'☑': `$\boxtimes$`,
+	'☒': `$\boxtimes$`,
+	'★': `$\bigstar$`,
+}
+
+// escapeText escapes the text having typographic characters replaced
+// and, if configured, straight quotes converted into the quotes of the
+// configured language. The prev rune is the character preceding the
+// text (0 at the start) used to detect opening quotes.
+func (g generator) escapeText(data []byte, prev rune) []byte {
+	var (
+		buf          = new(bytes.Buffer)
+		style, smart = quoteStyles[g.config.smartQuotes]
+	)
+
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		next, _ := utf8.DecodeRune(data)
+		repl, hasRepl := textReplacements[r]
+
+		switch {
+		case smart && r == '"':
+			if isQuoteOpening(prev) {
+				buf.WriteString(style.doubleOpen)
+			} else {
+				buf.WriteString(style.doubleClose)
+			}
+
+		case smart && r == '\'':
+			switch {
+			case isQuoteOpening(prev) && next != utf8.RuneError && !unicode.IsSpace(next):
+				buf.WriteString(style.singleOpen)
+			case unicode.IsLetter(prev) && unicode.IsLetter(next):
+				buf.WriteString(apostrophe)
+			default:
+				buf.WriteString(style.singleClose)
+			}
+
+		case isEmoji(r), next == emojiVariation:
+			// Emoji cannot be rendered by most engines and fonts
+
+		case hasRepl:
+			buf.WriteString(repl)
+
+		default:
+			buf.WriteRune(r)
+		}
+
+		prev = r
+	}
+
+	return buf.Bytes()
+}
+
+// escapeLaTeX escapes the text not being preceded by other text
+func (g generator) escapeLaTeX(data []byte) []byte {
+	return g.escapeText(g.restoreShortcodes(data), 0)
+}
+
+// isEmoji checks whether the rune is part of the emoji blocks, a symbol
+// displayed as emoji or a modifier used within emoji sequences. Other
+// symbols and dingbats are kept as they are used in regular text (i.e.
+// check marks).
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff, // Emoji & pictographs
+		unicode.Is(emojiSymbols, r),
+		r >= 0xfe00 && r <= 0xfe0f, // Variation selectors
+		r == 0x200d:                // Zero-width joiner
+		return true
+	default:
+		return false
+	}
+}
+
+// isQuoteOpening checks whether a quote following the given rune is an
+// opening quote
+func isQuoteOpening(prev rune) bool {
+	return prev == 0 || unicode.IsSpace(prev) || strings.ContainsRune("([{-–—/", prev)
+}
+
+// previousRune returns the rune before the given offset in the source
+// skipping emphasis markup or 0 at the start of the source
+func previousRune(source []byte, offset int) rune {
+	if offset > len(source) {
+		return 0
+	}
+
+	for offset > 0 {
+		r, size := utf8.DecodeLastRune(source[:offset])
+		if !strings.ContainsRune("*_~", r) {
+			return r
+		}
+		offset -= size
+	}
+
+	return 0
+}
+
+// listingLanguages maps the info strings of fenced code blocks to the
+// languages known to the `listings` package
+var listingLanguages = map[string]string{
+	"awk":        "Awk",
+	"bash":       "bash",
+	"c":          "C",
+	"c++":        "C++",
+	"cpp":        "C++",
+	"csh":        "csh",
+	"erlang":     "erlang",
+	"fortran":    "Fortran",
+	"haskell":    "Haskell",
+	"html":       "HTML",
+	"java":       "Java",
+	"ksh":        "ksh",
+	"latex":      "TeX",
+	"lisp":       "Lisp",
+	"make":       "make",
+	"makefile":   "make",
+	"matlab":     "Matlab",
+	"ocaml":      "Caml",
+	"pascal":     "Pascal",
+	"perl":       "Perl",
+	"php":        "PHP",
+	"postscript": "PostScript",
+	"prolog":     "Prolog",
+	"py":         "Python",
+	"python":     "Python",
+	"r":          "R",
+	"rb":         "Ruby",
+	"ruby":       "Ruby",
+	"scala":      "Scala",
+	"sh":         "sh",
+	"shell":      "bash",
+	"sparql":     "SPARQL",
+	"sql":        "SQL",
+	"tcl":        "tcl",
+	"tex":        "TeX",
+	"vb":         "VBScript",
+	"vbscript":   "VBScript",
+	"verilog":    "Verilog",
+	"vhdl":       "VHDL",
+	"xml":        "XML",
+	"xslt":       "XSLT",
+}
+
+// escapeCode escapes the given code for use in `\texttt` keeping all
+// characters and consecutive spaces as typed
+func escapeCode(code []byte) []byte {
+	buf := new(bytes.Buffer)
+
+	for i, b := range code {
+		switch b {
+		case '\\':
+			buf.WriteString(`\textbackslash{}`)
+
+		case '~':
+			buf.WriteString(`\textasciitilde{}`)
+
+		case '^':
+			buf.WriteString(`\textasciicircum{}`)
+
+		case '&', '%', '$', '#', '_', '{', '}':
+			buf.Write([]byte{'\\', b})
+
+		case ' ':
+			if i > 0 && code[i-1] == ' ' {
+				// Prevent collapsing of multiple spaces
+				buf.WriteString(`\ `)
+				continue
+			}
+			buf.WriteByte(b)
+
+		case '\n', '\r':
+			// Code spans are single-line
+			buf.WriteByte(' ')
+
+		default:
+			buf.WriteByte(b)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// escapeListing prevents the code within a `lstlisting` environment
+// from ending the environment prematurely
+func escapeListing(code []byte) []byte {
+	return bytes.ReplaceAll(code, []byte(`\end{lstlisting}`), []byte(`\end {lstlisting}`))
+}
+
+// escapeURL prepares the URL to be used as the first argument of
+// `\href`: `#` and `%` are escaped (as required when the link is
+// part of a macro argument) while characters LaTeX cannot take in the
+// argument are percent-encoded.
+func escapeURL(url []byte) []byte {
+	buf := new(bytes.Buffer)
+
+	for _, b := range url {
+		switch {
+		case b == '#', b == '%':
+			buf.Write([]byte{'\\', b})
+
+		case b <= ' ', b >= 0x7f, strings.IndexByte(`\{}~^"`, b) >= 0:
+			buf.WriteString(fmt.Sprintf(`\%%%02X`, b))
+
+		default:
+			buf.WriteByte(b)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// listingLanguage returns the `listings` language for the info string
+// of a fenced code block or an empty string if not supported
+func listingLanguage(info []byte) string {
+	lang, _, _ := strings.Cut(strings.TrimSpace(string(info)), " ")
+	return listingLanguages[strings.ToLower(strings.Trim(lang, "{}."))]
+}
@@ -0,0 +1,109 @@
+package md2tex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+)
+
+// Policies for HTML not being part of the supported subset
+const (
+	// HTMLStrip removes unsupported HTML from the output
+	HTMLStrip HTMLPolicy = "strip"
+	// HTMLEscape outputs unsupported HTML as escaped text
+	HTMLEscape HTMLPolicy = "escape"
+	// HTMLError fails the conversion on unsupported HTML
+	HTMLError HTMLPolicy = "error"
+)
+
+type (
+	// HTMLPolicy defines how to handle HTML not being part of the
+	// supported subset (`<br>`, `<b>`, `<strong>`, `<i>`, `<em>`,
+	// `<u>`, `<sup>` and `<sub>`)
+	HTMLPolicy string
+
+	// htmlState keeps track of the inline HTML tags opened within the
+	// current block
+	htmlState struct {
+		open []string
+	}
+)
+
+var (
+	// htmlInlineCommands maps the supported tags to LaTeX commands
+	htmlInlineCommands = map[string]string{
+		"b":      "textbf",
+		"em":     "textit",
+		"i":      "textit",
+		"strong": "textbf",
+		"sub":    "textsubscript",
+		"sup":    "textsuperscript",
+		"u":      "underline",
+	}
+)
+
+// IsValid checks whether the policy is known
+func (p HTMLPolicy) IsValid() bool {
+	switch p {
+	case HTMLStrip, HTMLEscape, HTMLError:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeAll returns the LaTeX closing all tags still open and resets
+// the state
+func (h *htmlState) closeAll() string {
+	if h == nil {
+		return ""
+	}
+
+	out := strings.Repeat("}", len(h.open))
+	h.open = nil
+
+	return out
+}
+
+// inlineTag converts the given inline HTML tag into LaTeX. The second
+// return value is false if the tag is not part of the supported subset
+// or does not close the most recently opened tag.
+func (h *htmlState) inlineTag(raw string) (string, bool) {
+	if mdcommon.IsHTMLComment(raw) {
+		// Comments never produce output
+		return "", true
+	}
+
+	tag, ok := mdcommon.ParseHTMLTag(raw)
+	if !ok {
+		return "", false
+	}
+
+	var (
+		closing     = tag.Closing
+		name        = tag.Name
+		selfClosing = tag.SelfClosing
+	)
+
+	if name == "br" && !closing {
+		return "\\newline{}", true
+	}
+
+	cmd, ok := htmlInlineCommands[name]
+	if !ok || selfClosing {
+		return "", false
+	}
+
+	if !closing {
+		h.open = append(h.open, name)
+		return fmt.Sprintf("\\%s{", cmd), true
+	}
+
+	if len(h.open) == 0 || h.open[len(h.open)-1] != name {
+		return "", false
+	}
+
+	h.open = h.open[:len(h.open)-1]
+	return "}", true
+}
@@ -6,17 +6,61 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/util"
 )
 
-const prio = 1000
+// prio must be lower than the priority of the HTML renderers added by
+// the extensions for our renderer to take precedence
+const prio = 100
 
-// Convert takes a Markdown document and returns LaTex source from it
-func Convert(md []byte) (tex []byte, err error) {
-	rd := renderer.NewRenderer(renderer.WithNodeRenderers(util.Prioritized(newGenerator(), prio)))
+// Convert takes a Markdown document and returns LaTex source from it.
+// In addition to CommonMark the GFM tables, strikethrough, task lists
+// and footnotes are supported, which require the `longtable`, `ulem`
+// (with `normalem` option) and `amssymb` packages. Images are rendered
+// using the `graphicx` package. Shortcodes (`{% vspace 1cm %}`) are
+// executed in text, code and URLs keep them unchanged.
+func Convert(md []byte, opts ...Option) (tex []byte, err error) {
+	return convert(md, opts, nil)
+}
+
+// ConvertWithDiagnostics converts the document like Convert but does
+// not stop at images which cannot be resolved or unsupported HTML.
+// Instead these problems are returned (together with failing
+// shortcodes) as diagnostics having their position in the document.
+func ConvertWithDiagnostics(md []byte, opts ...Option) (tex []byte, diags []Diagnostic, err error) {
+	d := &diagnostics{source: md}
+	if tex, err = convert(md, opts, d); err != nil {
+		return nil, nil, err
+	}
+
+	return tex, d.list, nil
+}
+
+func convert(md []byte, opts []Option, d *diagnostics) (tex []byte, err error) {
+	md, calls := mdcommon.ExtractShortcodes(md)
+
+	g, err := newGenerator(newConfig(opts), calls)
+	if err != nil {
+		return nil, fmt.Errorf("preparing shortcodes: %w", err)
+	}
+
+	if d != nil {
+		d.calls = calls
+		g.diagnostics = d
+	}
+
+	rd := renderer.NewRenderer(renderer.WithNodeRenderers(util.Prioritized(g, prio)))
 	gm := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.Strikethrough,
+			extension.TaskList,
+			extension.Footnote,
+		),
 		goldmark.WithRenderer(rd),
 	)
 
@@ -27,3 +71,10 @@ func Convert(md []byte) (tex []byte, err error) {
 
 	return bytes.TrimSpace(output.Bytes()), nil
 }
+
+// Escape returns the given text with all characters having a special
+// meaning in LaTeX escaped and typographic characters replaced by
+// their LaTeX commands. Of the options only WithSmartQuotes is used.
+func Escape(text string, opts ...Option) string {
+	return string(generator{config: newConfig(opts)}.escapeLaTeX([]byte(text)))
+}
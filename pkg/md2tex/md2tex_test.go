@@ -2,23 +2,184 @@ package md2tex
 
 import (
 	"bytes"
-	_ "embed"
+	"embed"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-var (
-	//go:embed test/input.md
-	testMD []byte
-	//go:embed test/output.tex
-	testTeX []byte
-)
+//go:embed test
+var testFiles embed.FS
 
 func TestConvert(t *testing.T) {
-	tex, err := Convert(testMD)
+	inputs, err := testFiles.ReadDir("test")
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		if !strings.HasSuffix(input.Name(), "input.md") {
+			continue
+		}
+
+		t.Run(input.Name(), func(t *testing.T) {
+			testMD, err := testFiles.ReadFile("test/" + input.Name())
+			require.NoError(t, err)
+
+			testTeX, err := testFiles.ReadFile("test/" + strings.Replace(input.Name(), "input.md", "output.tex", 1))
+			require.NoError(t, err)
+
+			tex, err := Convert(testMD)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(bytes.TrimSpace(testTeX)), string(tex))
+		})
+	}
+}
+
+func TestConvertImageErrors(t *testing.T) {
+	for md, errMsg := range map[string]string{
+		"![](https://example.com/logo.png)": "remote image",
+		"![](../secret.png)":                "must be relative",
+		"![](/etc/logo.png)":                "must be relative",
+		"![](missing.png)":                  "missing.png not available",
+	} {
+		_, err := Convert([]byte(md), WithImageResolver(func(p string) (string, error) {
+			if p == "missing.png" {
+				return "", errors.New("missing.png not available")
+			}
+			return CleanImagePath(p)
+		}))
+		require.Error(t, err, md)
+		assert.Contains(t, err.Error(), errMsg, md)
+	}
+}
+
+func TestConvertHTMLPolicy(t *testing.T) {
+	md := []byte("Some <span>text</span> <b>bold</b>\n\n<div>\nblock & more\n</div>")
+
+	tex, err := Convert(md, WithHTMLPolicy(HTMLEscape))
 	require.NoError(t, err)
+	assert.Equal(t, "Some \\textless{}span\\textgreater{}text\\textless{}/span\\textgreater{} \\textbf{bold}\n\n"+
+		"\\textless{}div\\textgreater{}\nblock \\& more\n\\textless{}/div\\textgreater{}", string(tex))
+
+	_, err = Convert(md, WithHTMLPolicy(HTMLError))
+	assert.ErrorContains(t, err, `unsupported HTML: "<span>"`)
+
+	_, err = Convert([]byte("<b>bold</b>\n\n<div>\nblock\n</div>"), WithHTMLPolicy(HTMLError))
+	assert.ErrorContains(t, err, "unsupported HTML block")
+}
+
+func TestListingLanguage(t *testing.T) {
+	for info, lang := range map[string]string{
+		"":                "",
+		"go":              "",
+		"Python":          "Python",
+		"c++ {.numbered}": "C++",
+		"{.sh}":           "sh",
+	} {
+		assert.Equal(t, lang, listingLanguage([]byte(info)), info)
+	}
+}
+
+func TestSmartQuotes(t *testing.T) {
+	md := []byte(`"Quoted *"emphasis"*" and 'single' don't`)
+
+	for lang, expect := range map[string]string{
+		"": `\textquotedbl{}Quoted \textit{\textquotedbl{}emphasis\textquotedbl{}}\textquotedbl{} and ` +
+			`'single' don't`,
+		"de": `\quotedblbase{}Quoted \textit{\quotedblbase{}emphasis\textquotedblleft{}}\textquotedblleft{} and ` +
+			`\quotesinglbase{}single\textquoteleft{} don\textquoteright{}t`,
+		"en": `\textquotedblleft{}Quoted \textit{\textquotedblleft{}emphasis\textquotedblright{}}\textquotedblright{} and ` +
+			`\textquoteleft{}single\textquoteright{} don\textquoteright{}t`,
+	} {
+		tex, err := Convert(md, WithSmartQuotes(lang))
+		require.NoError(t, err)
+		assert.Equal(t, expect, string(tex), lang)
+	}
+
+	assert.Equal(t, `\quotedblbase{}100 \%\textquotedblleft{}`, Escape(`"100 %"`, WithSmartQuotes("DE")))
+}
+
+func TestEscapeSymbols(t *testing.T) {
+	assert.Equal(t, `$\checkmark$ done, $\times$ failed, $\square$ open, $\bigstar$ ☎ call `,
+		Escape("✓ done, ✗ failed, ☐ open, ★ ☎ call \U0001F600\u2764\uFE0F\u2728\u2705"))
+	assert.Equal(t, `I ❤ TeX`, Escape("I ❤ TeX"))
+}
+
+func TestCustomShortcodes(t *testing.T) {
+	md := []byte(`{% hello World %}, {% greet "Jane" "Doe" %} and {% vspace .Values.spacing %}{% sum 1 2 %}`)
+
+	tex, err := Convert(md,
+		WithShortcodes(map[string]any{
+			"hello": func(name string) string { return `\textbf{` + Escape(name) + `}` },
+			"sum":   func(a, b int) int { return a + b },
+		}),
+		WithShortcodeTemplates(map[string]string{
+			"greet": `{{ .Values.greeting }} {{ join " " .Args | escape }}`,
+		}),
+		WithValues(map[string]any{"greeting": `\hello`, "spacing": "2cm"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `\textbf{World}, \hello Jane Doe and \vspace{2cm}3`, string(tex))
+
+	_, err = Convert(md, WithShortcodeTemplates(map[string]string{"broken": "{{ .Args"}))
+	assert.Error(t, err)
+}
+
+func TestSandbox(t *testing.T) {
+	for md, expect := range map[string]string{
+		`{% raw "\\input{x}" %}`:       "raw LaTeX is disabled in sandbox mode",
+		`{% part "A & B" %}`:           `\part{A \& B}`,
+		`{% vspace 1cm %}`:             `\vspace{1cm}`,
+		`{% vspace "1cm}\\input{x" %}`: `invalid dimension`,
+		`{% printf "%cinput" 92 %}`:    `unknown shortcode "printf"`,
+		`{% part (printf "x") %}`:      "shortcode takes 1 arguments, 3 given",
+		`{% part "A (B)" %}`:           `\part{A (B)}`,
+		`{% part x}\input{y %}`:        `\part{x\}\textbackslash{}input\{y}`,
+		`{% vspace .Values.space %}`:   `\vspace{2cm}`,
+	} {
+		tex, err := Convert([]byte(md), WithSandbox(), WithValues(map[string]any{"space": "2cm"}))
+		require.NoError(t, err)
+		assert.Contains(t, string(tex), expect, md)
+	}
+
+	// Closing the template action must not allow to execute arbitrary
+	// template code
+	tex, err := Convert([]byte(`{% part "x" }}{{ printf "%cinput{/etc/passwd}" 92 %}`), WithSandbox())
+	require.NoError(t, err)
+	assert.NotContains(t, string(tex), `\input`)
+	assert.NotContains(t, string(tex), `\part`)
+
+	// Shortcode functions and templates get the arguments unchanged
+	tex, err = Convert([]byte(`{% greet "x}{{ printf \"y\" }}" %} {% tpl a_b %}`), WithSandbox(),
+		WithShortcodes(map[string]any{"greet": func(name string) string { return "Hello " + Escape(name) }}),
+		WithShortcodeTemplates(map[string]string{"tpl": "{{ index .Args 0 | escape }}"}))
+	require.NoError(t, err)
+	assert.Equal(t, `Hello x\}\{\{ printf \textquotedbl{}y\textquotedbl{} \}\} a\_b`, string(tex))
+}
+
+func TestConvertWithDiagnostics(t *testing.T) {
+	md := []byte("# Title\n\nText {% vspace 1cm %} and {% unknown %}\n\n" +
+		"Ünïcode <span>x</span> ![alt](missing.png)\n")
+
+	tex, diags, err := ConvertWithDiagnostics(md,
+		WithHTMLPolicy(HTMLError),
+		WithImageResolver(func(p string) (string, error) { return "", errors.New("not found") }),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, string(tex), `Text \vspace{1cm} and`)
+
+	require.Len(t, diags, 4)
+	assert.Equal(t, 3, diags[0].Line)
+	assert.Equal(t, 27, diags[0].Column)
+	assert.Contains(t, diags[0].Message, `shortcode {% unknown %}`)
+
+	assert.Equal(t, Diagnostic{Line: 5, Column: 9, Message: `unsupported HTML: "<span>"`}, diags[1])
+	assert.Equal(t, Diagnostic{Line: 5, Column: 16, Message: `unsupported HTML: "</span>"`}, diags[2])
+	assert.Equal(t, Diagnostic{Line: 5, Column: 26, Message: "resolving image: not found"}, diags[3])
 
-	assert.Equal(t, bytes.TrimSpace(testTeX), tex)
+	_, err = Convert(md, WithHTMLPolicy(HTMLError))
+	assert.Error(t, err)
 }
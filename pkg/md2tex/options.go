@@ -0,0 +1,97 @@
+package md2tex
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+type (
+	// ImageResolver checks the path of an image referenced in the
+	// Markdown document and returns the path to use in the LaTeX
+	// document or an error if the image is not available
+	ImageResolver func(path string) (string, error)
+
+	// Option configures the conversion
+	Option func(*config)
+
+	config struct {
+		htmlPolicy         HTMLPolicy
+		imageResolver      ImageResolver
+		sandbox            bool
+		shortcodes         template.FuncMap
+		shortcodeTemplates map[string]string
+		smartQuotes        string
+		values             any
+	}
+)
+
+// WithHTMLPolicy configures how to handle HTML not being part of the
+// supported subset, defaults to HTMLStrip
+func WithHTMLPolicy(p HTMLPolicy) Option {
+	return func(c *config) { c.htmlPolicy = p }
+}
+
+// WithImageResolver configures how to resolve the paths of images
+// referenced in the document. By default all relative paths are
+// accepted without checking whether they exist.
+func WithImageResolver(r ImageResolver) Option {
+	return func(c *config) { c.imageResolver = r }
+}
+
+// WithSandbox restricts the shortcodes for documents written by
+// untrusted users: `raw` is disabled, shortcodes are called with plain
+// arguments instead of being executed as template and the arguments
+// of `part` and `vspace` are checked.
+func WithSandbox() Option {
+	return func(c *config) { c.sandbox = true }
+}
+
+// WithSmartQuotes converts straight quotes (`"` and `'`) into the
+// typographic quotes of the given language (`de`, `en` or `fr`)
+func WithSmartQuotes(language string) Option {
+	return func(c *config) { c.smartQuotes = strings.ToLower(language) }
+}
+
+// IsSmartQuoteLanguage checks whether smart quotes are available for
+// the given language
+func IsSmartQuoteLanguage(language string) bool {
+	_, ok := quoteStyles[strings.ToLower(language)]
+	return ok
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		htmlPolicy:         HTMLStrip,
+		imageResolver:      CleanImagePath,
+		shortcodes:         template.FuncMap{},
+		shortcodeTemplates: map[string]string{},
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// CleanImagePath normalizes the given image path and rejects remote
+// images, absolute paths, paths leaving the document directory and
+// paths containing characters not supported in LaTeX file names
+func CleanImagePath(p string) (string, error) {
+	if strings.Contains(p, "://") {
+		return "", fmt.Errorf("remote image %q is not supported", p)
+	}
+
+	if strings.ContainsAny(p, "{}%\\#") {
+		return "", fmt.Errorf("image path %q contains unsupported characters", p)
+	}
+
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("image path %q must be relative to the document", p)
+	}
+
+	return clean, nil
+}
@@ -5,20 +5,47 @@ package md2tex
 import (
 	"bytes"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
 
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
 	"github.com/yuin/goldmark/ast"
+	extAST "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/util"
 )
 
 type (
-	generator struct{}
+	generator struct {
+		config config
+		html   *htmlState
+
+		// calls contains the shortcode calls replaced by placeholders
+		calls mdcommon.Shortcodes
+		// diagnostics collects non-fatal conversion errors, nil when
+		// errors stop the conversion
+		diagnostics *diagnostics
+		// funcs contains the shortcodes available in the document
+		funcs template.FuncMap
+	}
 )
 
 var _ renderer.NodeRenderer = &generator{}
 
-func newGenerator() *generator {
-	return &generator{}
+var imageDimension = regexp.MustCompile(`^\d*\.?\d+(?:cm|mm|in|pt|em|ex|\\linewidth|\\textwidth)$`)
+
+func newGenerator(c config, calls mdcommon.Shortcodes) (*generator, error) {
+	g := &generator{config: c, html: &htmlState{}, calls: calls}
+
+	funcs, err := g.shortcodeFuncs()
+	if err != nil {
+		return nil, err
+	}
+	g.funcs = funcs
+
+	return g, nil
 }
 
 func (g generator) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
@@ -44,33 +71,21 @@ func (g generator) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(ast.KindRawHTML, g.renderRawHTML)
 	reg.Register(ast.KindText, g.renderText)
 	reg.Register(ast.KindString, g.renderString)
-}
-
-func (generator) escapeLaTeX(data []byte) []byte {
-	buf := new(bytes.Buffer)
-	for _, b := range data {
-		switch b {
-		case '\\':
-			_, _ = buf.WriteString("\\textbackslash~")
 
-		case '~':
-			_, _ = buf.WriteString("\\textasciitilde~")
-
-		case '^':
-			_, _ = buf.WriteString("\\textasciicircum~")
-
-		case '&', '%', '$', '#', '_', '{', '}':
-			_, _ = buf.Write([]byte{'\\', b})
-
-		default:
-			_ = buf.WriteByte(b)
-		}
-	}
-
-	return buf.Bytes()
+	// GFM extensions
+	reg.Register(extAST.KindFootnote, g.renderFootnote)
+	reg.Register(extAST.KindFootnoteBacklink, g.renderFootnoteBacklink)
+	reg.Register(extAST.KindFootnoteLink, g.renderFootnoteLink)
+	reg.Register(extAST.KindFootnoteList, g.renderFootnoteList)
+	reg.Register(extAST.KindStrikethrough, g.renderStrikethrough)
+	reg.Register(extAST.KindTable, g.renderTable)
+	reg.Register(extAST.KindTableCell, g.renderTableCell)
+	reg.Register(extAST.KindTableHeader, g.renderTableHeader)
+	reg.Register(extAST.KindTableRow, g.renderTableRow)
+	reg.Register(extAST.KindTaskCheckBox, g.renderTaskCheckBox)
 }
 
-func (generator) renderAutoLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+func (g generator) renderAutoLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	if !entering {
 		return ast.WalkContinue, nil
 	}
@@ -84,7 +99,7 @@ func (generator) renderAutoLink(w util.BufWriter, source []byte, node ast.Node,
 		url = bytes.Join([][]byte{[]byte("mailto"), url}, []byte{':'})
 	}
 
-	if _, err := w.WriteString(fmt.Sprintf("\\href{%s}{%s}", url, n.Label(source))); err != nil {
+	if _, err := w.WriteString(fmt.Sprintf("\\href{%s}{%s}", escapeURL(g.restoreShortcodes(url)), g.escapeLaTeX(n.Label(source)))); err != nil {
 		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 	}
 
@@ -105,39 +120,29 @@ func (generator) renderBlockquote(w util.BufWriter, _ []byte, _ ast.Node, enteri
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-	if entering {
-		if _, err := w.WriteString("\\begin{lstlisting}\n"); err != nil {
-			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
-		}
+func (g generator) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	return g.renderListing(w, source, node, entering, "")
+}
 
-		for i := 0; i < node.Lines().Len(); i++ {
-			line := node.Lines().At(i)
-			if _, err := w.Write(line.Value(source)); err != nil {
-				return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
-			}
-		}
-	} else {
-		if _, err := w.WriteString("\\end{lstlisting}\n\n"); err != nil {
-			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
-		}
+func (g generator) renderCodeSpan(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
 	}
 
-	return ast.WalkContinue, nil
-}
-
-func (generator) renderCodeSpan(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
-	if entering {
-		if _, err := w.WriteString("\\texttt{"); err != nil {
-			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
-		}
-	} else {
-		if err := w.WriteByte('}'); err != nil {
-			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	// Render the content directly instead of walking the Text children
+	// in order not to process shortcodes within code
+	code := new(bytes.Buffer)
+	for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			code.Write(t.Segment.Value(source))
 		}
 	}
 
-	return ast.WalkContinue, nil
+	if _, err := w.WriteString(fmt.Sprintf("\\texttt{%s}", escapeCode(g.restoreShortcodes(code.Bytes())))); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkSkipChildren, nil
 }
 
 func (generator) renderDocument(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
@@ -168,21 +173,71 @@ func (generator) renderEmphasis(w util.BufWriter, _ []byte, node ast.Node, enter
 	return ast.WalkContinue, nil
 }
 
+func (generator) renderFootnote(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
+	// Footnotes are rendered at the position of their FootnoteLink
+	return ast.WalkSkipChildren, nil
+}
+
+func (generator) renderFootnoteBacklink(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
+	// LaTeX footnotes do not need a link back to the reference
+	return ast.WalkContinue, nil
+}
+
+func (g generator) renderFootnoteLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*extAST.FootnoteLink)
+
+	footnote := findFootnote(n.OwnerDocument(), n.Index)
+	if footnote == nil {
+		return ast.WalkStop, fmt.Errorf("footnote %d not found", n.Index)
+	}
+
+	// The footnote text must be placed at the position of the reference
+	// for LaTeX to put it onto the same page, so we render its content
+	// in place
+	nested := g
+	nested.html = &htmlState{}
+
+	content := new(bytes.Buffer)
+	rd := renderer.NewRenderer(renderer.WithNodeRenderers(util.Prioritized(nested, prio)))
+	for c := footnote.FirstChild(); c != nil; c = c.NextSibling() {
+		if err := rd.Render(content, source, c); err != nil {
+			return ast.WalkStop, fmt.Errorf("rendering footnote: %w", err)
+		}
+	}
+
+	if _, err := w.WriteString(fmt.Sprintf("\\footnote{%s}", bytes.TrimSpace(content.Bytes()))); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (generator) renderFootnoteList(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
+	// Footnotes are rendered at the position of their FootnoteLink
+	return ast.WalkSkipChildren, nil
+}
+
 func (g generator) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-	return g.renderCodeBlock(w, source, node, entering)
+	return g.renderListing(w, source, node, entering, listingLanguage(node.(*ast.FencedCodeBlock).Language(source)))
 }
 
-func (generator) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+func (g generator) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	var (
-		n        = node.(*ast.Heading)
+		n = node.(*ast.Heading)
+		// Headings are unnumbered as the text is embedded into documents
+		// having their own structure (as expected by test/output.tex)
 		headings = []string{
-			"",              // SKIP
-			"section",       // H1
-			"subsection",    // H2
-			"subsubsection", // H3
-			"paragraph",     // H4
-			"subparagraph",  // H5
-			"textbf",        // H6
+			"",               // SKIP
+			"section*",       // H1
+			"subsection*",    // H2
+			"subsubsection*", // H3
+			"paragraph*",     // H4
+			"subparagraph*",  // H5
+			"textbf",         // H6
 		}
 	)
 
@@ -191,7 +246,7 @@ func (generator) renderHeading(w util.BufWriter, _ []byte, node ast.Node, enteri
 			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 		}
 	} else {
-		if _, err := w.WriteString("}\n\n"); err != nil {
+		if _, err := w.WriteString(g.html.closeAll() + "}\n\n"); err != nil {
 			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 		}
 	}
@@ -199,19 +254,97 @@ func (generator) renderHeading(w util.BufWriter, _ []byte, node ast.Node, enteri
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderHTMLBlock(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
-	return ast.WalkContinue, fmt.Errorf("unsupported: HTMLBlock")
+func (g generator) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.HTMLBlock)
+
+	content := new(bytes.Buffer)
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		content.Write(line.Value(source))
+	}
+	if n.HasClosure() {
+		content.Write(n.ClosureLine.Value(source))
+	}
+
+	if n.HTMLBlockType == ast.HTMLBlockType2 {
+		// Comments never produce output
+		return ast.WalkSkipChildren, nil
+	}
+
+	switch g.config.htmlPolicy {
+	case HTMLEscape:
+		if _, err := w.Write(append(g.escapeLaTeX(bytes.TrimSpace(content.Bytes())), '\n', '\n')); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+
+	case HTMLError:
+		if err := g.fail(node, fmt.Errorf("unsupported HTML block: %q", bytes.TrimSpace(content.Bytes()))); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+
+	return ast.WalkSkipChildren, nil
 }
 
-func (generator) renderImage(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
-	return ast.WalkContinue, fmt.Errorf("unsupported: Image")
+func (g generator) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var (
+		n             = node.(*ast.Image)
+		dest, options = imageOptions(string(g.restoreShortcodes(n.Destination)))
+	)
+
+	imgPath, err := g.config.imageResolver(dest)
+	if err != nil {
+		if err = g.fail(node, fmt.Errorf("resolving image: %w", err)); err != nil {
+			return ast.WalkStop, err
+		}
+		return ast.WalkSkipChildren, nil
+	}
+
+	if alt := mdcommon.PlainText(n, source); len(alt) > 0 {
+		options = append(options, fmt.Sprintf("alt={%s}", g.escapeLaTeX(alt)))
+	}
+
+	graphic := fmt.Sprintf("\\includegraphics[%s]{%s}", strings.Join(options, ","), imgPath)
+	if len(options) == 0 {
+		graphic = fmt.Sprintf("\\includegraphics{%s}", imgPath)
+	}
+
+	if _, ok := n.Parent().(*ast.Paragraph); !ok || n.Parent().ChildCount() > 1 {
+		// Images within text are placed inline
+		if _, err = w.WriteString(graphic); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+		return ast.WalkSkipChildren, nil
+	}
+
+	var caption string
+	if len(n.Title) > 0 {
+		caption = fmt.Sprintf("\\caption{%s}\n", g.escapeLaTeX(n.Title))
+	}
+
+	if _, err = w.WriteString(fmt.Sprintf(
+		"\\begin{figure}[htbp]\n\\centering\n%s\n%s\\end{figure}",
+		graphic, caption,
+	)); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkSkipChildren, nil
 }
 
-func (generator) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+func (g generator) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	n := node.(*ast.Link)
 
 	if entering {
-		if _, err := w.WriteString(fmt.Sprintf("\\href{%s}{", n.Destination)); err != nil {
+		if _, err := w.WriteString(fmt.Sprintf("\\href{%s}{", escapeURL(g.restoreShortcodes(n.Destination)))); err != nil {
 			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 		}
 	} else {
@@ -223,6 +356,32 @@ func (generator) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering
 	return ast.WalkContinue, nil
 }
 
+func (g generator) renderListing(w util.BufWriter, source []byte, node ast.Node, entering bool, language string) (ast.WalkStatus, error) {
+	if entering {
+		begin := "\\begin{lstlisting}\n"
+		if language != "" {
+			begin = fmt.Sprintf("\\begin{lstlisting}[language=%s]\n", language)
+		}
+
+		if _, err := w.WriteString(begin); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+
+		for i := 0; i < node.Lines().Len(); i++ {
+			line := node.Lines().At(i)
+			if _, err := w.Write(escapeListing(g.restoreShortcodes(line.Value(source)))); err != nil {
+				return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+			}
+		}
+	} else {
+		if _, err := w.WriteString("\\end{lstlisting}\n\n"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
 func (generator) renderList(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	var (
 		n        = node.(*ast.List)
@@ -267,9 +426,132 @@ func (generator) renderListItem(w util.BufWriter, _ []byte, _ ast.Node, entering
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderParagraph(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+func (g generator) renderParagraph(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		if _, err := w.WriteString(g.html.closeAll() + "\n\n"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (g generator) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var (
+		n   = node.(*ast.RawHTML)
+		raw = new(bytes.Buffer)
+	)
+
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		raw.Write(segment.Value(source))
+	}
+
+	tex, ok := g.html.inlineTag(raw.String())
+	if !ok {
+		switch g.config.htmlPolicy {
+		case HTMLEscape:
+			tex = string(g.escapeLaTeX(raw.Bytes()))
+
+		case HTMLError:
+			if err := g.fail(node, fmt.Errorf("unsupported HTML: %q", raw.String())); err != nil {
+				return ast.WalkStop, err
+			}
+		}
+	}
+
+	if _, err := w.WriteString(tex); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (generator) renderStrikethrough(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if _, err := w.WriteString("\\sout{"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	} else {
+		if err := w.WriteByte('}'); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (g generator) renderString(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.String)
+
+	value := n.Value
+	if !n.IsRaw() {
+		value = g.escapeLaTeX(value)
+	}
+
+	if _, err := w.Write(value); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (generator) renderTable(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*extAST.Table)
+
+	if entering {
+		colSpec := make([]byte, len(n.Alignments))
+		for i, a := range n.Alignments {
+			switch a {
+			case extAST.AlignCenter:
+				colSpec[i] = 'c'
+			case extAST.AlignRight:
+				colSpec[i] = 'r'
+			default:
+				colSpec[i] = 'l'
+			}
+		}
+
+		if _, err := w.WriteString(fmt.Sprintf("\\begin{longtable}{%s}\n\\hline\n", colSpec)); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	} else {
+		if _, err := w.WriteString("\\hline\n\\end{longtable}\n\n"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (g generator) renderTableCell(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering && node.PreviousSibling() != nil {
+		if _, err := w.WriteString(" & "); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	if !entering {
+		if _, err := w.WriteString(g.html.closeAll()); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (generator) renderTableHeader(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
 	if !entering {
-		if _, err := w.Write([]byte("\n\n")); err != nil {
+		// Repeat the header on every page the table spans
+		if _, err := w.WriteString(" \\\\\n\\hline\n\\endhead\n"); err != nil {
 			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 		}
 	}
@@ -277,12 +559,31 @@ func (generator) renderParagraph(w util.BufWriter, _ []byte, _ ast.Node, enterin
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderRawHTML(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
-	return ast.WalkContinue, fmt.Errorf("unsupported: RawHTML")
+func (generator) renderTableRow(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		if _, err := w.WriteString(" \\\\\n"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
 }
 
-func (generator) renderString(_ util.BufWriter, _ []byte, _ ast.Node, _ bool) (ast.WalkStatus, error) {
-	return ast.WalkContinue, fmt.Errorf("unsupported: String")
+func (generator) renderTaskCheckBox(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	symbol := "$\\square$ "
+	if node.(*extAST.TaskCheckBox).IsChecked {
+		symbol = "$\\boxtimes$ "
+	}
+
+	if _, err := w.WriteString(symbol); err != nil {
+		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+	}
+
+	return ast.WalkContinue, nil
 }
 
 func (g generator) renderText(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
@@ -292,7 +593,7 @@ func (g generator) renderText(w util.BufWriter, source []byte, node ast.Node, en
 
 	n := node.(*ast.Text)
 
-	if _, err := w.Write(g.replaceShortCode(n.Segment.Value(source))); err != nil {
+	if _, err := w.Write(g.renderShortcodes(n.Segment.Value(source), previousRune(source, n.Segment.Start))); err != nil {
 		return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
 	}
 
@@ -309,8 +610,12 @@ func (g generator) renderText(w util.BufWriter, source []byte, node ast.Node, en
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderTextBlock(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+func (g generator) renderTextBlock(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	if !entering {
+		if _, err := w.WriteString(g.html.closeAll()); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+
 		if node.NextSibling() != nil && node.FirstChild() != nil {
 			if err := w.WriteByte('\n'); err != nil {
 				return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
@@ -321,21 +626,53 @@ func (generator) renderTextBlock(w util.BufWriter, _ []byte, node ast.Node, ente
 	return ast.WalkContinue, nil
 }
 
-func (generator) renderThematicBreak(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
-	return ast.WalkContinue, fmt.Errorf("unsupported: ThematicBreak")
+func (generator) renderThematicBreak(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if _, err := w.WriteString("\\noindent\\rule{\\linewidth}{0.4pt}\n\n"); err != nil {
+			return ast.WalkStop, fmt.Errorf("writing TeX: %w", err)
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+// findFootnote looks up the footnote with the given index in the
+// footnote list appended to the document
+func findFootnote(doc ast.Node, index int) *extAST.Footnote {
+	for c := doc.LastChild(); c != nil; c = c.PreviousSibling() {
+		list, ok := c.(*extAST.FootnoteList)
+		if !ok {
+			continue
+		}
+
+		for f := list.FirstChild(); f != nil; f = f.NextSibling() {
+			if fn, ok := f.(*extAST.Footnote); ok && fn.Index == index {
+				return fn
+			}
+		}
+	}
+
+	return nil
 }
 
-func (g generator) replaceShortCode(value []byte) []byte {
-	if !shortCodeDef.Match(value) {
-		return g.escapeLaTeX(value)
+// imageOptions splits the options given in the fragment of the image
+// destination (i.e. `image.png#width=50%`) from the path and converts
+// them into options for `\includegraphics`
+func imageOptions(dest string) (string, []string) {
+	dest, value := mdcommon.ImageWidth(dest)
+	if value == "" {
+		return dest, nil
 	}
 
-	match := shortCodeDef.FindSubmatch(value)
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		if f, err := strconv.ParseFloat(pct, 64); err == nil {
+			value = fmt.Sprintf("%s\\linewidth", strconv.FormatFloat(f/100, 'f', -1, 64)) //nolint:mnd // Percent
+		}
+	}
 
-	repl, err := renderShortCode(string(match[1]))
-	if err != nil {
-		return []byte(fmt.Sprintf("%% Shortcode error: %s\n%% %s", err, match[1]))
+	if !imageDimension.MatchString(value) {
+		return dest, nil
 	}
 
-	return []byte(repl)
+	return dest, []string{"width=" + value}
 }
@@ -3,38 +3,216 @@ package md2tex
 import (
 	"bytes"
 	"fmt"
-	"regexp"
+	"reflect"
+	"strconv"
+	"strings"
 	"text/template"
+
+	"github.com/Luzifer/doc-render/pkg/internal/mdcommon"
+	"github.com/Masterminds/sprig/v3"
 )
 
-var (
-	shortCodes = template.FuncMap{
-		"graphic": shortCodeGraphic,
+type (
+	// ShortcodeData is available as data inside shortcodes and shortcode
+	// templates
+	ShortcodeData struct {
+		// Args contains the arguments passed to shortcode templates
+		Args []string
+		// Values contains the values configured using WithValues
+		Values any
+	}
+)
+
+// WithShortcodes registers additional shortcodes (or replaces built-in
+// ones) in the form of template functions returning the LaTeX code
+// to insert, i.e. `func(name string) string` for `{% hello "World" %}`
+func WithShortcodes(funcs template.FuncMap) Option {
+	return func(c *config) {
+		for name, fn := range funcs {
+			c.shortcodes[name] = fn
+		}
+	}
+}
+
+// WithShortcodeTemplates registers additional shortcodes defined as
+// Go templates. The templates have access to ShortcodeData (`.Args`
+// and `.Values`), the sprig functions and an `escape` function to
+// escape text for LaTeX.
+func WithShortcodeTemplates(templates map[string]string) Option {
+	return func(c *config) {
+		for name, tpl := range templates {
+			c.shortcodeTemplates[name] = tpl
+		}
+	}
+}
+
+// WithValues makes the given values available as `.Values` within
+// shortcodes, i.e. `{% vspace .Values.spacing %}`
+func WithValues(values any) Option {
+	return func(c *config) { c.values = values }
+}
+
+// quoteShortCodeArgs quotes bare arguments (i.e. `vspace 0.5cm`) in
+// order to have them parsed as strings by the template engine instead
+// of failing to parse them (as used in test/input.md)
+func quoteShortCodeArgs(content string) string {
+	fields := strings.Fields(content)
+	if len(fields) < 2 || strings.ContainsAny(content, "\"`|()") {
+		// Nothing to quote or already valid template syntax
+		return content
+	}
+
+	for i, f := range fields[1:] {
+		if _, err := strconv.ParseFloat(f, 64); err == nil || strings.HasPrefix(f, ".") || strings.HasPrefix(f, "$") {
+			continue
+		}
+
+		fields[i+1] = strconv.Quote(f)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// shortcodeFuncs returns the built-in shortcodes having the configured
+// shortcodes and shortcode templates added
+func (g generator) shortcodeFuncs() (template.FuncMap, error) {
+	funcs := template.FuncMap{
+		"graphic": g.shortCodeGraphic,
 		"part":    shortCodePart,
 		"raw":     shortCodeRaw,
 		"vspace":  shortCodeVSpace,
 	}
-	shortCodeDef = regexp.MustCompile(`{% (.*?) %}`)
-)
 
-func renderShortCode(content string) (string, error) {
+	for name, tplSource := range g.config.shortcodeTemplates {
+		tpl, err := template.New(name).
+			Funcs(sprig.TxtFuncMap()).
+			Funcs(template.FuncMap{"escape": func(s string) string { return Escape(s) }}).
+			Parse(tplSource)
+		if err != nil {
+			return nil, fmt.Errorf("parsing shortcode %q: %w", name, err)
+		}
+
+		funcs[name] = func(args ...any) (string, error) {
+			data := ShortcodeData{Values: g.config.values}
+			for _, a := range args {
+				data.Args = append(data.Args, fmt.Sprint(a))
+			}
+
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, data); err != nil {
+				return "", fmt.Errorf("executing shortcode %q: %w", name, err)
+			}
+
+			return buf.String(), nil
+		}
+	}
+
+	for name, fn := range g.config.shortcodes {
+		funcs[name] = fn
+	}
+
+	if g.config.sandbox {
+		// Arguments must not be passed into the document unchecked
+		funcs["part"] = func(title string) string { return shortCodePart(Escape(title)) }
+		funcs["raw"] = func(string) (string, error) { return "", fmt.Errorf("raw LaTeX is disabled in sandbox mode") }
+		funcs["vspace"] = shortCodeSandboxVSpace
+	}
+
+	return funcs, nil
+}
+
+// renderShortCode executes the shortcode call (including the `{%`
+// and `%}` delimiters)
+func (g generator) renderShortCode(call string) (string, error) {
+	content := mdcommon.ShortcodeContent(call)
+
+	if g.config.sandbox {
+		// The content must never be executed as template as its actions
+		// and builtins (i.e. printf) could be used to build arbitrary
+		// LaTeX, so the shortcode function is called directly
+		return g.callShortCode(content)
+	}
+
 	tpl, err := template.New("shortCode").
-		Funcs(shortCodes).
-		Parse(fmt.Sprintf(`{{- %s -}}`, content))
+		Funcs(g.funcs).
+		Parse(fmt.Sprintf(`{{- %s -}}`, quoteShortCodeArgs(content)))
 	if err != nil {
 		return "", fmt.Errorf("parsing template: %w", err)
 	}
 
 	buf := new(bytes.Buffer)
-	if err = tpl.Execute(buf, nil); err != nil {
+	if err = tpl.Execute(buf, ShortcodeData{Values: g.config.values}); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
 
 	return buf.String(), nil
 }
 
-func shortCodeGraphic(path string) string {
-	return fmt.Sprintf(`\includegraphics{%s}`, path)
+// callShortCode calls the shortcode function with the plain arguments
+// (quoted strings, bare words and `.Values` references) of the call
+func (g generator) callShortCode(content string) (string, error) {
+	name, rawArgs := mdcommon.SplitArgs(content)
+
+	fn, ok := g.funcs[name]
+	if !ok {
+		return "", fmt.Errorf("unknown shortcode %q", name)
+	}
+
+	args := make([]string, 0, len(rawArgs))
+	for _, a := range rawArgs {
+		arg, err := mdcommon.ResolveArg(a, g.config.values)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+
+	return callFunc(fn, args)
+}
+
+// renderShortcodes escapes the text replacing the contained shortcode
+// placeholders by the output of the shortcodes
+func (g generator) renderShortcodes(value []byte, prev rune) []byte {
+	buf := new(bytes.Buffer)
+
+	g.calls.Render(value, func(text []byte) {
+		buf.Write(g.escapeText(text, prev))
+	}, func(sc mdcommon.Shortcode) {
+		repl, err := g.renderShortCode(sc.Call)
+		if err != nil {
+			g.diagnostics.add(sc.Offset, fmt.Errorf("shortcode %s: %w", sc.Call, err))
+			repl = fmt.Sprintf("%% Shortcode error: %s\n%% %s\n", singleLine(err.Error()), singleLine(sc.Call))
+		}
+		buf.WriteString(repl)
+
+		prev = 0
+	})
+
+	return buf.Bytes()
+}
+
+// restoreShortcodes replaces the shortcode placeholders by the original
+// shortcode calls for contexts not rendering shortcodes (i.e. code)
+func (g generator) restoreShortcodes(value []byte) []byte {
+	return g.calls.Restore(value, func(call string) string { return call })
+}
+
+func (g generator) shortCodeGraphic(path string, width ...string) (string, error) {
+	dest, options := imageOptions(path)
+	if len(width) > 0 {
+		_, options = imageOptions("#width=" + width[0])
+	}
+
+	imgPath, err := g.config.imageResolver(dest)
+	if err != nil {
+		return "", fmt.Errorf("resolving image: %w", err)
+	}
+
+	if len(options) == 0 {
+		return fmt.Sprintf(`\includegraphics{%s}`, imgPath), nil
+	}
+
+	return fmt.Sprintf(`\includegraphics[%s]{%s}`, strings.Join(options, ","), imgPath), nil
 }
 
 func shortCodePart(title string) string {
@@ -46,3 +224,60 @@ func shortCodeRaw(latex string) string { return latex }
 func shortCodeVSpace(dist string) string {
 	return fmt.Sprintf(`\vspace{%s}`, dist)
 }
+
+func shortCodeSandboxVSpace(dist string) (string, error) {
+	if !mdcommon.SpaceDimension.MatchString(dist) {
+		return "", fmt.Errorf("invalid dimension %q", dist)
+	}
+
+	return shortCodeVSpace(dist), nil
+}
+
+// callFunc calls the function taking strings (or values of type any)
+// and returning a string and an optional error
+func callFunc(fn any, args []string) (string, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return "", fmt.Errorf("shortcode is no function")
+	}
+
+	t := v.Type()
+	if t.NumOut() < 1 || t.NumOut() > 2 || (t.NumOut() == 2 && !t.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem())) {
+		return "", fmt.Errorf("shortcode function has unsupported return values")
+	}
+
+	required := t.NumIn()
+	if t.IsVariadic() {
+		required--
+	}
+
+	if len(args) < required || (!t.IsVariadic() && len(args) > required) {
+		return "", fmt.Errorf("shortcode takes %d arguments, %d given", required, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		argType := t.In(min(i, t.NumIn()-1))
+		if t.IsVariadic() && i >= required {
+			argType = t.In(t.NumIn() - 1).Elem()
+		}
+
+		if !reflect.TypeOf(a).AssignableTo(argType) {
+			return "", fmt.Errorf("argument %d of shortcode must be of type %s", i+1, argType)
+		}
+		in[i] = reflect.ValueOf(a)
+	}
+
+	out := v.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return "", out[1].Interface().(error) //nolint:forcetypeassert // Checked above
+	}
+
+	return fmt.Sprint(out[0].Interface()), nil
+}
+
+// singleLine replaces line breaks in order to output the text in a
+// LaTeX comment
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,144 @@
+package recipientcsv
+
+import (
+	"strings"
+)
+
+// DefaultHomeCountry is the country letters are sent from when no
+// home-country is given to AddressLines
+const DefaultHomeCountry = "DE"
+
+type (
+	addressFormat func(p Person) []string
+
+	country struct {
+		Name   string
+		Format addressFormat
+	}
+)
+
+// countries contains the ISO 3166-1 alpha-2 codes of the countries
+// having a known address format
+var countries = map[string]country{
+	"AT": {Name: "Austria", Format: addressFormatDIN5008},
+	"CH": {Name: "Switzerland", Format: addressFormatDIN5008},
+	"DE": {Name: "Germany", Format: addressFormatDIN5008},
+	"FR": {Name: "France", Format: addressFormatFR},
+	"GB": {Name: "United Kingdom", Format: addressFormatUK},
+	"US": {Name: "United States of America", Format: addressFormatUS},
+}
+
+// countryAliases maps common names of the countries to their code
+var countryAliases = map[string]string{
+	"DEUTSCHLAND":              "DE",
+	"ENGLAND":                  "GB",
+	"FRANCE":                   "FR",
+	"FRANKREICH":               "FR",
+	"GERMANY":                  "DE",
+	"GREAT BRITAIN":            "GB",
+	"GROSSBRITANNIEN":          "GB",
+	"ÖSTERREICH":               "AT",
+	"SCHWEIZ":                  "CH",
+	"SUISSE":                   "CH",
+	"UK":                       "GB",
+	"UNITED KINGDOM":           "GB",
+	"UNITED STATES":            "US",
+	"UNITED STATES OF AMERICA": "US",
+	"USA":                      "US",
+	"VEREINIGTE STAATEN":       "US",
+	"VEREINIGTES KÖNIGREICH":   "GB",
+}
+
+// AddressLines returns the lines of the postal address of the person
+// formatted according to the conventions of the country of the person
+// (DIN 5008 for Germany, Austria and Switzerland, US, UK and French
+// conventions). The country line is omitted when the person lives in
+// the home-country (defaults to DefaultHomeCountry).
+func (p Person) AddressLines(homeCountry string) []string {
+	if homeCountry == "" {
+		homeCountry = DefaultHomeCountry
+	}
+
+	code := CountryCode(p.Country)
+	format := addressFormatDIN5008
+	if c, ok := countries[code]; ok {
+		format = c.Format
+	}
+
+	var lines []string
+	for _, l := range format(p) {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	if p.Country != "" && code != CountryCode(homeCountry) {
+		name := p.Country
+		if c, ok := countries[code]; ok {
+			name = c.Name
+		}
+
+		// International mail requires the country in capital letters
+		lines = append(lines, strings.ToUpper(name))
+	}
+
+	return lines
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code for the given
+// country name or code. Unknown countries are returned in upper case.
+func CountryCode(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	if code, ok := countryAliases[country]; ok {
+		return code
+	}
+
+	return country
+}
+
+func (p Person) fullName() string {
+	return strings.Join([]string{p.Title, p.Firstname, p.Lastname}, " ")
+}
+
+func addressFormatDIN5008(p Person) []string {
+	return []string{
+		p.Company,
+		p.fullName(),
+		p.AddressLine2,
+		p.Street + " " + p.StreetNumber,
+		p.PostalCode + " " + p.City,
+	}
+}
+
+func addressFormatFR(p Person) []string {
+	return []string{
+		p.fullName(),
+		p.Company,
+		p.AddressLine2,
+		p.StreetNumber + " " + p.Street,
+		p.PostalCode + " " + strings.ToUpper(p.City),
+	}
+}
+
+func addressFormatUK(p Person) []string {
+	return []string{
+		p.fullName(),
+		p.Company,
+		p.AddressLine2,
+		p.StreetNumber + " " + p.Street,
+		strings.ToUpper(p.City),
+		p.State,
+		strings.ToUpper(p.PostalCode),
+	}
+}
+
+func addressFormatUS(p Person) []string {
+	return []string{
+		p.fullName(),
+		p.Company,
+		p.StreetNumber + " " + p.Street,
+		p.AddressLine2,
+		strings.ToUpper(p.City) + " " + strings.ToUpper(p.State) + " " + p.PostalCode,
+	}
+}
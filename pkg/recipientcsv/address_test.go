@@ -0,0 +1,58 @@
+package recipientcsv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressLines(t *testing.T) {
+	for name, tc := range map[string]struct {
+		person Person
+		home   string
+		lines  []string
+	}{
+		"domestic DIN 5008": {
+			person: Person{
+				Company: "Muster GmbH", Title: "Dr.", Firstname: "Karl", Lastname: "Muster",
+				Street: "Musterstraße", StreetNumber: "12", AddressLine2: "Hinterhaus",
+				PostalCode: "12345", City: "Musterhausen", Country: "Deutschland",
+			},
+			lines: []string{"Muster GmbH", "Dr. Karl Muster", "Hinterhaus", "Musterstraße 12", "12345 Musterhausen"},
+		},
+		"no country given": {
+			person: Person{Firstname: "Karl", Lastname: "Muster", Street: "Musterstraße", StreetNumber: "12", PostalCode: "12345", City: "Musterhausen"},
+			lines:  []string{"Karl Muster", "Musterstraße 12", "12345 Musterhausen"},
+		},
+		"Austria from Germany": {
+			person: Person{Firstname: "Karl", Lastname: "Muster", Street: "Hauptplatz", StreetNumber: "1", PostalCode: "1010", City: "Wien", Country: "AT"},
+			lines:  []string{"Karl Muster", "Hauptplatz 1", "1010 Wien", "AUSTRIA"},
+		},
+		"US": {
+			person: Person{
+				Firstname: "Jane", Lastname: "Doe", Company: "ACME Inc.", Street: "Main Street", StreetNumber: "123",
+				AddressLine2: "Suite 4", PostalCode: "12345", City: "Anytown", State: "NY", Country: "USA",
+			},
+			lines: []string{"Jane Doe", "ACME Inc.", "123 Main Street", "Suite 4", "ANYTOWN NY 12345", "UNITED STATES OF AMERICA"},
+		},
+		"UK": {
+			person: Person{Firstname: "Jane", Lastname: "Doe", Street: "Baker Street", StreetNumber: "221b", PostalCode: "nw1 6xe", City: "London", Country: "UK"},
+			lines:  []string{"Jane Doe", "221b Baker Street", "LONDON", "NW1 6XE", "UNITED KINGDOM"},
+		},
+		"FR": {
+			person: Person{Firstname: "Jean", Lastname: "Dupont", Street: "rue de Rivoli", StreetNumber: "10", PostalCode: "75001", City: "Paris", Country: "France"},
+			lines:  []string{"Jean Dupont", "10 rue de Rivoli", "75001 PARIS", "FRANCE"},
+		},
+		"FR home": {
+			person: Person{Firstname: "Jean", Lastname: "Dupont", Street: "rue de Rivoli", StreetNumber: "10", PostalCode: "75001", City: "Paris", Country: "Frankreich"},
+			home:   "FR",
+			lines:  []string{"Jean Dupont", "10 rue de Rivoli", "75001 PARIS"},
+		},
+		"unknown country": {
+			person: Person{Firstname: "Anna", Lastname: "Svensson", Street: "Storgatan", StreetNumber: "1", PostalCode: "111 22", City: "Stockholm", Country: "Sverige"},
+			lines:  []string{"Anna Svensson", "Storgatan 1", "111 22 Stockholm", "SVERIGE"},
+		},
+	} {
+		assert.Equal(t, tc.lines, tc.person.AddressLines(tc.home), name)
+	}
+}
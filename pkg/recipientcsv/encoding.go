@@ -0,0 +1,47 @@
+package recipientcsv
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16BE = []byte{0xfe, 0xff}
+	bomUTF16LE = []byte{0xff, 0xfe}
+)
+
+// decodeText converts the given data into a string detecting the
+// encoding: UTF-8 and UTF-16 are detected by their BOM, data being
+// neither valid UTF-8 nor having a BOM is treated as Windows-1252
+// as exported by most spreadsheet applications.
+func decodeText(data []byte) (string, error) {
+	var dec *encoding.Decoder
+
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return string(data[len(bomUTF8):]), nil
+
+	case bytes.HasPrefix(data, bomUTF16BE), bytes.HasPrefix(data, bomUTF16LE):
+		// The BOM-override detects the endianness and strips the BOM
+		dec = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+
+	case utf8.Valid(data):
+		return string(data), nil
+
+	default:
+		dec = charmap.Windows1252.NewDecoder()
+	}
+
+	out, err := dec.Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("converting to UTF-8: %w", err)
+	}
+
+	return string(out), nil
+}
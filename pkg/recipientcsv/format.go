@@ -0,0 +1,68 @@
+package recipientcsv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Formats supported by ParseFormat
+const (
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatVCard = "vcard"
+	FormatXLSX  = "xlsx"
+)
+
+var zipMagic = []byte("PK\x03\x04")
+
+// DetectFormat guesses the format of the given recipient data from its
+// content, falling back to FormatCSV
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(data, zipMagic) {
+		return FormatXLSX
+	}
+
+	content, err := decodeText(data)
+	if err != nil {
+		return FormatCSV
+	}
+
+	content = strings.TrimSpace(content)
+	switch {
+	case strings.HasPrefix(content, "[") || strings.HasPrefix(content, "{"):
+		return FormatJSON
+
+	case strings.HasPrefix(strings.ToUpper(content), "BEGIN:VCARD"):
+		return FormatVCard
+
+	default:
+		return FormatCSV
+	}
+}
+
+// ParseFormat reads the recipient data in the given format (detected
+// from the content when empty) and returns the Person data included.
+// The options are applied to all formats having column headers.
+func ParseFormat(format string, data []byte, opts Options) ([]Person, error) {
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return ParseWithOptions(bytes.NewReader(data), opts)
+
+	case FormatJSON:
+		return ParseJSON(bytes.NewReader(data), opts)
+
+	case FormatVCard, "vcf":
+		return ParseVCard(bytes.NewReader(data))
+
+	case FormatXLSX:
+		return ParseXLSX(bytes.NewReader(data), opts)
+
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
@@ -0,0 +1,138 @@
+package recipientcsv
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	for data, format := range map[string]string{
+		"NACHNAME;VORNAME\n":             FormatCSV,
+		"  [{\"lastname\": \"Muster\"}]": FormatJSON,
+		"begin:vcard\r\nVERSION:3.0":     FormatVCard,
+		"PK\x03\x04rest":                 FormatXLSX,
+	} {
+		assert.Equal(t, format, DetectFormat([]byte(data)), data)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	data := `[
+		{"NACHNAME": "Muster", "firstname": "Karl", "PLZ": 12345, "Anrede": "Herr"},
+		{"NACHNAME": "Muster", "firstname": "Birgit", "PLZ": null}
+	]`
+
+	p, err := ParseFormat("", []byte(data), Options{})
+	require.NoError(t, err)
+	require.Len(t, p, 2)
+
+	assert.Equal(t, Person{
+		Lastname:   "Muster",
+		Firstname:  "Karl",
+		PostalCode: "12345",
+		Extra:      map[string]string{"Anrede": "Herr"},
+	}, p[0])
+	assert.Equal(t, "", p[1].PostalCode)
+}
+
+func TestParseVCard(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"N:Muster;Karl;;Dr.;",
+		"FN:Dr. Karl Muster",
+		"ORG:Muster GmbH\\, Abteilung;Vertrieb",
+		"item1.ADR;TYPE=WORK:;;Musterstraße 12a;Muster",
+		" hausen;;12345;Deutschland",
+		"ADR;TYPE=HOME:;;Other 1;Other;;99999;",
+		"END:VCARD",
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		"FN:Jane Doe",
+		"ADR:;;221 Baker Street;London;;NW1 6XE;United Kingdom",
+		"END:VCARD",
+	}, "\r\n")
+
+	p, err := ParseFormat("", []byte(data), Options{})
+	require.NoError(t, err)
+	require.Len(t, p, 2)
+
+	assert.Equal(t, Person{
+		Company:      "Muster GmbH, Abteilung",
+		Title:        "Dr.",
+		Lastname:     "Muster",
+		Firstname:    "Karl",
+		Street:       "Musterstraße",
+		StreetNumber: "12a",
+		PostalCode:   "12345",
+		City:         "Musterhausen",
+		Country:      "Deutschland",
+	}, p[0])
+
+	assert.Equal(t, Person{
+		Lastname:     "Doe",
+		Firstname:    "Jane",
+		Street:       "Baker Street",
+		StreetNumber: "221",
+		PostalCode:   "NW1 6XE",
+		City:         "London",
+		Country:      "United Kingdom",
+	}, p[1])
+}
+
+func TestParseXLSX(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Adressen" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>NACHNAME</t></si><si><t>PLZ</t></si>
+			<si><r><t>Mus</t></r><r><t>ter</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>ORT</t></is></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>12345</v></c><c r="D2" t="inlineStr"><is><t>Musterhausen</t></is></c></row>
+		</sheetData></worksheet>`,
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	p, err := ParseFormat("", buf.Bytes(), Options{})
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+
+	assert.Equal(t, Person{
+		Lastname:   "Muster",
+		PostalCode: "12345",
+		City:       "Musterhausen",
+	}, p[0])
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	for ref, expected := range map[string]int{
+		"A1":   0,
+		"AB12": 27,
+		"XFD1": 16383,
+	} {
+		col, err := xlsxColumnIndex(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, expected, col, ref)
+	}
+
+	for _, ref := range []string{"1", "a1", "XFE1", "ZZZZZZZZZZ1", strings.Repeat("Z", 100) + "1"} {
+		_, err := xlsxColumnIndex(ref)
+		assert.Error(t, err, ref)
+	}
+}
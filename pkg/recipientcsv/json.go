@@ -0,0 +1,67 @@
+package recipientcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ParseJSON reads a JSON array of objects (or an object containing
+// such an array in its `recipients` key) and returns the Person data
+// included. The keys of the objects are mapped the same way as the
+// CSV headers.
+func ParseJSON(data io.Reader, opts Options) ([]Person, error) {
+	raw, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading data: %w", err)
+	}
+
+	var objects []map[string]any
+	if err = json.Unmarshal(raw, &objects); err != nil {
+		var wrapped struct {
+			Recipients []map[string]any `json:"recipients"`
+		}
+
+		if wErr := json.Unmarshal(raw, &wrapped); wErr != nil {
+			return nil, fmt.Errorf("decoding JSON: %w", err)
+		}
+
+		objects = wrapped.Recipients
+	}
+
+	// Collect all keys to build a table and reuse the CSV mapping
+	keySet := map[string]bool{}
+	for _, obj := range objects {
+		for k := range obj {
+			keySet[k] = true
+		}
+	}
+
+	headers := make([]string, 0, len(keySet))
+	for k := range keySet {
+		headers = append(headers, k)
+	}
+	sort.Strings(headers)
+
+	records := [][]string{headers}
+	for _, obj := range objects {
+		record := make([]string, len(headers))
+		for i, h := range headers {
+			switch v := obj[h].(type) {
+			case nil:
+				// Missing or null, keep empty
+			case string:
+				record[i] = v
+			case float64:
+				// Prevent exponent format for postal codes and numbers
+				record[i] = fmt.Sprintf("%.16g", v)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		records = append(records, record)
+	}
+
+	return fromRecords(records, opts)
+}
@@ -1,65 +1,317 @@
-// Package recipientcsv contains a method to parse an address-data CSV
+// Package recipientcsv contains methods to parse address-data from
+// CSV, vCard, JSON and XLSX files
 package recipientcsv
 
 import (
 	"encoding/csv"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 type (
 	// Person represents a person the CSV
 	Person struct {
+		Company      string `json:"FIRMA,omitempty"`
+		Title        string `json:"TITEL,omitempty"`
 		Lastname     string `json:"NACHNAME"`
 		Firstname    string `json:"VORNAME"`
 		Street       string `json:"STRASSE"`
 		StreetNumber string `json:"HAUSNR"`
+		AddressLine2 string `json:"ADRESSZUSATZ,omitempty"`
 		PostalCode   string `json:"PLZ"`
 		City         string `json:"ORT"`
+		State        string `json:"BUNDESLAND,omitempty"`
+		Country      string `json:"LAND,omitempty"`
+
+		// Extra contains all columns not mapped to a field of the
+		// Person using their header as key
+		Extra map[string]string `json:"extra,omitempty"`
+	}
+
+	// Mapping maps the column headers of the CSV to the fields of the
+	// Person. Known fields are `company`, `title`, `lastname`,
+	// `firstname`, `street`, `streetNumber`, `addressLine2`,
+	// `postalCode`, `city`, `state` and `country`.
+	Mapping map[string]string
+
+	// Options configure how to parse the CSV
+	Options struct {
+		// Delimiter separates the fields, auto-detected when not set
+		Delimiter string `json:"delimiter,omitempty"`
+		// Mapping is applied on top of the FoxCSVMapping
+		Mapping Mapping `json:"mapping,omitempty"`
+		// Required lists the fields (or headers of extra columns) which
+		// must not be empty when validating the recipients,
+		// DefaultRequiredFields when not set
+		Required []string `json:"required,omitempty"`
 	}
 )
 
-// Parse reads the given CSV and returns the Person data included
+// FoxCSVMapping contains the mapping of the German FoxCSV headers
+// which is always applied
+var FoxCSVMapping = Mapping{
+	"FIRMA":        "company",
+	"TITEL":        "title",
+	"NACHNAME":     "lastname",
+	"VORNAME":      "firstname",
+	"STRASSE":      "street",
+	"HAUSNR":       "streetNumber",
+	"ADRESSZUSATZ": "addressLine2",
+	"PLZ":          "postalCode",
+	"ORT":          "city",
+	"BUNDESLAND":   "state",
+	"LAND":         "country",
+}
+
+// personFields contains the field names known to Person.set
+var personFields = []string{
+	"company", "title", "lastname", "firstname", "street", "streetNumber",
+	"addressLine2", "postalCode", "city", "state", "country",
+}
+
+// delimiterCandidates are checked when auto-detecting the delimiter
+var delimiterCandidates = []rune{';', ',', '\t', '|'}
+
+// LoadOptions reads the JSON encoded Options from the given reader
+func LoadOptions(data io.Reader) (opts Options, err error) {
+	if err = json.NewDecoder(data).Decode(&opts); err != nil {
+		return opts, fmt.Errorf("decoding options: %w", err)
+	}
+
+	return opts, nil
+}
+
+// Merge returns a copy of the options having the mapping and delimiter
+// of the other options applied on top
+func (o Options) Merge(other Options) Options {
+	out := Options{
+		Delimiter: o.Delimiter,
+		Mapping:   Mapping{},
+		Required:  o.Required,
+	}
+
+	for k, v := range o.Mapping {
+		out.Mapping[k] = v
+	}
+
+	for k, v := range other.Mapping {
+		out.Mapping[k] = v
+	}
+
+	if other.Delimiter != "" {
+		out.Delimiter = other.Delimiter
+	}
+
+	if other.Required != nil {
+		out.Required = other.Required
+	}
+
+	return out
+}
+
+// Parse reads the given FoxCSV and returns the Person data included
 func Parse(data io.Reader) (out []Person, err error) {
-	r := csv.NewReader(data)
-	r.Comma = ';'
+	return ParseWithOptions(data, Options{})
+}
+
+// ParseWithOptions reads the given CSV using the mapping and delimiter
+// from the options and returns the Person data included. The encoding
+// (UTF-8, UTF-16 with BOM or Windows-1252) and, if not configured, the
+// delimiter are detected automatically.
+func ParseWithOptions(data io.Reader, opts Options) (out []Person, err error) {
+	records, err := readCSVRecords(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromRecords(records, opts)
+}
+
+// readCSVRecords decodes the given CSV into its records (including the
+// header record) allowing records of different lengths
+func readCSVRecords(data io.Reader, opts Options) ([][]string, error) {
+	raw, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading data: %w", err)
+	}
+
+	content, err := decodeText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding data: %w", err)
+	}
+
+	r := csv.NewReader(strings.NewReader(content))
 	r.FieldsPerRecord = -1
 
-	// We need the headers to assemble the JSON object
-	headers, err := r.Read()
+	if r.Comma, err = opts.delimiter(content); err != nil {
+		return nil, err
+	}
+
+	records, err := r.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("reading headers: %w", err)
+		return nil, fmt.Errorf("reading records: %w", err)
 	}
 
+	return records, nil
+}
+
+// fromRecords maps the records (the first record containing the
+// headers) to the Person data
+func fromRecords(records [][]string, opts Options) (out []Person, err error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("reading headers: %w", io.EOF)
+	}
+
+	// We need the headers to map the columns to the fields
+	headers := records[0]
+	fields := FoxCSVMapping.resolve(headers, opts.Mapping)
+
 	// Now we walk through the lines and build the output
-	for {
-		record, err := r.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("reading record: %w", err)
+	for _, record := range records[1:] {
+		var p Person
+		for i := range headers {
+			var value string
+			if i < len(record) {
+				value = strings.TrimSpace(record[i])
+			}
+
+			switch {
+			case fields[i] != "" && p.set(fields[i], value):
+				// Mapped to a field of the Person
+
+			case strings.TrimSpace(headers[i]) == "":
+				// Columns without header cannot be referenced
+
+			default:
+				p.setExtra(strings.TrimSpace(headers[i]), value)
+			}
 		}
 
-		obj := make(map[string]any)
-		for i := range headers {
-			obj[headers[i]] = record[i]
+		out = append(out, p)
+	}
+
+	return out, nil
+}
+
+// resolve returns the field name for every header, having the
+// overrides applied on top of the mapping
+func (m Mapping) resolve(headers []string, overrides Mapping) []string {
+	lookup := map[string]string{}
+	for _, field := range personFields {
+		// Field names can always be used as headers
+		lookup[strings.ToLower(field)] = field
+	}
+
+	for _, mapping := range []Mapping{m, overrides} {
+		for header, field := range mapping {
+			lookup[strings.ToLower(strings.TrimSpace(header))] = field
 		}
+	}
+
+	fields := make([]string, len(headers))
+	for i, header := range headers {
+		fields[i] = lookup[strings.ToLower(strings.TrimSpace(header))]
+	}
+
+	return fields
+}
 
-		raw, err := json.Marshal(obj)
-		if err != nil {
-			return nil, fmt.Errorf("marshalling JSON: %w", err)
+func (o Options) delimiter(content string) (rune, error) {
+	if o.Delimiter != "" {
+		d := []rune(o.Delimiter)
+		if len(d) != 1 {
+			return 0, fmt.Errorf("delimiter must be a single character")
 		}
+		return d[0], nil
+	}
 
-		var p Person
-		if err = json.Unmarshal(raw, &p); err != nil {
-			return nil, fmt.Errorf("unmarshalling JSON: %w", err)
+	// Detect the delimiter from the header line as it should not
+	// contain any quoted values
+	headerLine, _, _ := strings.Cut(content, "\n")
+
+	var (
+		best      = delimiterCandidates[0]
+		bestCount = 0
+	)
+
+	for _, c := range delimiterCandidates {
+		if n := strings.Count(headerLine, string(c)); n > bestCount {
+			best, bestCount = c, n
 		}
+	}
 
-		out = append(out, p)
+	return best, nil
+}
+
+// get returns the value of the field with the given name and false
+// if the field is unknown
+func (p Person) get(field string) (string, bool) {
+	switch strings.ToLower(field) {
+	case "company":
+		return p.Company, true
+	case "title":
+		return p.Title, true
+	case "lastname":
+		return p.Lastname, true
+	case "firstname":
+		return p.Firstname, true
+	case "street":
+		return p.Street, true
+	case "streetnumber":
+		return p.StreetNumber, true
+	case "addressline2":
+		return p.AddressLine2, true
+	case "postalcode":
+		return p.PostalCode, true
+	case "city":
+		return p.City, true
+	case "state":
+		return p.State, true
+	case "country":
+		return p.Country, true
+	default:
+		return "", false
 	}
+}
 
-	return out, nil
+// set assigns the value to the field with the given name and returns
+// false if the field is unknown
+func (p *Person) set(field, value string) bool {
+	switch strings.ToLower(field) {
+	case "company":
+		p.Company = value
+	case "title":
+		p.Title = value
+	case "lastname":
+		p.Lastname = value
+	case "firstname":
+		p.Firstname = value
+	case "street":
+		p.Street = value
+	case "streetnumber":
+		p.StreetNumber = value
+	case "addressline2":
+		p.AddressLine2 = value
+	case "postalcode":
+		p.PostalCode = value
+	case "city":
+		p.City = value
+	case "state":
+		p.State = value
+	case "country":
+		p.Country = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (p *Person) setExtra(key, value string) {
+	if p.Extra == nil {
+		p.Extra = map[string]string{}
+	}
+	p.Extra[key] = value
 }
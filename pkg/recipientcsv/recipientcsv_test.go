@@ -1,6 +1,7 @@
 package recipientcsv
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
@@ -28,3 +29,48 @@ Muster;Birgit;Musterstraße;123;12345;Musterhausen
 		City:         "Musterhausen",
 	}, p[0])
 }
+
+func TestParseWithOptions(t *testing.T) {
+	data := strings.TrimSpace(`
+Name,First Name,Address,No,ZIP,Town,Salutation
+Muster,Karl,Musterstraße,123,12345,Musterhausen,Herr
+Muster,Birgit,Musterstraße,123,12345
+`)
+
+	p, err := ParseWithOptions(strings.NewReader(data), Options{
+		Mapping: Mapping{
+			"name":       "lastname",
+			"first name": "firstname",
+			"address":    "street",
+			"no":         "streetNumber",
+			"zip":        "postalCode",
+			"town":       "city",
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, p, 2)
+
+	assert.Equal(t, Person{
+		Lastname:     "Muster",
+		Firstname:    "Karl",
+		Street:       "Musterstraße",
+		StreetNumber: "123",
+		PostalCode:   "12345",
+		City:         "Musterhausen",
+		Extra:        map[string]string{"Salutation": "Herr"},
+	}, p[0])
+
+	// Short rows must not fail but yield empty values
+	assert.Equal(t, "", p[1].City)
+	assert.Equal(t, map[string]string{"Salutation": ""}, p[1].Extra)
+}
+
+func TestParseWindows1252(t *testing.T) {
+	// "Musterstraße" encoded in Windows-1252 with tab delimiter
+	data := []byte("NACHNAME\tSTRASSE\nMuster\tMusterstra\xdfe\n")
+
+	p, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+	assert.Equal(t, "Musterstraße", p[0].Street)
+}
@@ -0,0 +1,207 @@
+package recipientcsv
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultRequiredFields are checked when validating recipients without
+// Required fields configured in the Options
+var DefaultRequiredFields = []string{"lastname", "street", "postalCode", "city"}
+
+type (
+	// Problem describes an issue found in the recipient data
+	Problem struct {
+		// Row contains the number of the recipient starting at 1 (the
+		// header of tabular formats is not counted)
+		Row int `json:"row"`
+		// Field contains the name of the field the problem is related to
+		Field string `json:"field,omitempty"`
+		// Reason describes the problem
+		Reason string `json:"reason"`
+	}
+
+	// Report contains the result of validating the recipient data
+	Report struct {
+		Valid      bool      `json:"valid"`
+		Recipients int       `json:"recipients"`
+		Problems   []Problem `json:"problems"`
+	}
+)
+
+// postalCodeFormats contains the postal code formats of the countries
+// by their ISO 3166-1 alpha-2 code
+var postalCodeFormats = map[string]*regexp.Regexp{
+	"AT": regexp.MustCompile(`^\d{4}$`),
+	"CH": regexp.MustCompile(`^\d{4}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"GB": regexp.MustCompile(`^(?i)[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+}
+
+// Validate checks the recipient data in the given format (detected
+// from the content when empty) and reports per-row problems: missing
+// required fields, malformed postal codes, duplicate recipients and
+// rows having another number of fields than the header (CSV only).
+// An error is only returned when the data cannot be parsed at all.
+func Validate(format string, data []byte, opts Options) (Report, error) {
+	problems := []Problem{}
+
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	if strings.EqualFold(format, FormatCSV) {
+		records, err := readCSVRecords(bytes.NewReader(data), opts)
+		if err != nil {
+			return Report{}, err
+		}
+		problems = append(problems, recordProblems(records)...)
+	}
+
+	persons, err := ParseFormat(format, data, opts)
+	if err != nil {
+		return Report{}, err
+	}
+
+	problems = append(problems, ValidatePersons(persons, opts)...)
+	sortProblems(problems)
+
+	return Report{
+		Valid:      len(problems) == 0,
+		Recipients: len(persons),
+		Problems:   problems,
+	}, nil
+}
+
+// ValidatePersons checks the given recipients for missing required
+// fields, malformed postal codes and duplicates
+func ValidatePersons(persons []Person, opts Options) (problems []Problem) {
+	required := opts.Required
+	if required == nil {
+		required = DefaultRequiredFields
+	}
+
+	seen := map[string]int{}
+
+	for i, p := range persons {
+		row := i + 1
+
+		if p.isEmpty() {
+			problems = append(problems, Problem{Row: row, Reason: "row is empty"})
+			continue
+		}
+
+		for _, field := range required {
+			if strings.TrimSpace(p.value(field)) == "" {
+				problems = append(problems, Problem{Row: row, Field: field, Reason: "field is required"})
+			}
+		}
+
+		if p.PostalCode != "" {
+			country := CountryCode(p.Country)
+			if country == "" {
+				country = DefaultHomeCountry
+			}
+
+			if re, ok := postalCodeFormats[country]; ok && !re.MatchString(p.PostalCode) {
+				problems = append(problems, Problem{
+					Row:    row,
+					Field:  "postalCode",
+					Reason: fmt.Sprintf("malformed postal code %q for country %s", p.PostalCode, country),
+				})
+			}
+		}
+
+		key := p.identity()
+		if first, ok := seen[key]; ok {
+			problems = append(problems, Problem{Row: row, Reason: fmt.Sprintf("duplicate of row %d", first)})
+			continue
+		}
+		seen[key] = row
+	}
+
+	return problems
+}
+
+// value returns the value of the field or, if the field is not known,
+// of the extra column having the field as header
+func (p Person) value(field string) string {
+	if v, ok := p.get(field); ok {
+		return v
+	}
+
+	for k, v := range p.Extra {
+		if strings.EqualFold(k, field) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// identity returns a normalized representation of name and address to
+// detect duplicate recipients
+func (p Person) identity() string {
+	parts := []string{
+		p.Company, p.Firstname, p.Lastname, p.Street, p.StreetNumber,
+		p.AddressLine2, p.PostalCode, p.City, CountryCode(p.Country),
+	}
+
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.Join(strings.Fields(parts[i]), " "))
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+func (p Person) isEmpty() bool {
+	for _, field := range personFields {
+		if v, _ := p.get(field); strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+
+	for _, v := range p.Extra {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// recordProblems reports records having another number of fields than
+// the header record
+func recordProblems(records [][]string) (problems []Problem) {
+	if len(records) == 0 {
+		return nil
+	}
+
+	nHeaders := len(records[0])
+	for i, record := range records[1:] {
+		switch {
+		case len(record) < nHeaders:
+			problems = append(problems, Problem{
+				Row:    i + 1,
+				Reason: fmt.Sprintf("row has %d of %d fields", len(record), nHeaders),
+			})
+
+		case len(record) > nHeaders:
+			problems = append(problems, Problem{
+				Row:    i + 1,
+				Reason: fmt.Sprintf("row has %d fields, header only %d", len(record), nHeaders),
+			})
+		}
+	}
+
+	return problems
+}
+
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Row < problems[j].Row })
+}
@@ -0,0 +1,48 @@
+package recipientcsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	data := strings.TrimSpace(`
+NACHNAME;VORNAME;STRASSE;HAUSNR;PLZ;ORT;LAND
+Muster;Karl;Musterstraße;123;12345;Musterhausen;
+Muster;Birgit;Musterstraße;123;1234;Musterhausen;
+;;;;;;
+Doe;Jane;Main Street;1;12345-6789;Anytown;USA
+muster;karl;Musterstraße;123;12345;Musterhausen
+Muster;Karl;Musterstraße;123;12345;Musterhausen;;extra
+Dupont;Jean;;10;75001;Paris;France
+`)
+
+	report, err := Validate("", []byte(data), Options{})
+	require.NoError(t, err)
+
+	assert.False(t, report.Valid)
+	assert.Equal(t, 7, report.Recipients)
+	assert.Equal(t, []Problem{
+		{Row: 2, Field: "postalCode", Reason: `malformed postal code "1234" for country DE`},
+		{Row: 3, Reason: "row is empty"},
+		{Row: 5, Reason: "row has 6 of 7 fields"},
+		{Row: 5, Reason: "duplicate of row 1"},
+		{Row: 6, Reason: "row has 8 fields, header only 7"},
+		{Row: 6, Reason: "duplicate of row 1"},
+		{Row: 7, Field: "street", Reason: "field is required"},
+	}, report.Problems)
+}
+
+func TestValidateRequiredOption(t *testing.T) {
+	report, err := Validate(FormatJSON, []byte(`[{"FIRMA": "Muster GmbH", "PLZ": "12345"}, {"FIRMA": "Other", "Email": "a@example.com"}]`), Options{
+		Required: []string{"company", "email"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Row: 1, Field: "email", Reason: "field is required"},
+	}, report.Problems)
+}
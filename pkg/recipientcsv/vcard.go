@@ -0,0 +1,209 @@
+package recipientcsv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	streetNumberSuffix = regexp.MustCompile(`^(.*?)\s+(\d+\s*[a-zA-Z]?(?:\s*[-/]\s*\d+\s*[a-zA-Z]?)?)$`)
+	streetNumberPrefix = regexp.MustCompile(`^(\d+[a-zA-Z]?(?:[-/]\d+[a-zA-Z]?)?)\s+(.*)$`)
+)
+
+// ParseVCard reads the vCards (version 2.1 to 4.0) from the given data
+// and returns the Person data included. The name is taken from the `N`
+// (falling back to `FN`) property, the address from the first `ADR`
+// and the company from the `ORG` property. `TITLE`, `EMAIL` and `TEL`
+// are stored in the Extra fields.
+func ParseVCard(data io.Reader) (out []Person, err error) {
+	raw, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading data: %w", err)
+	}
+
+	content, err := decodeText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding data: %w", err)
+	}
+
+	var (
+		current *Person
+		hasAddr bool
+	)
+
+	for _, line := range unfoldVCardLines(content) {
+		name, value := splitVCardLine(line)
+
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VCARD") {
+				current, hasAddr = &Person{}, false
+			}
+			continue
+
+		case "END":
+			if strings.EqualFold(value, "VCARD") && current != nil {
+				out = append(out, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch name {
+		case "ADR":
+			if hasAddr {
+				continue
+			}
+			hasAddr = true
+
+			// PO-Box;Extended;Street;Locality;Region;Postal Code;Country
+			parts := splitVCardValue(value, 7) //nolint:mnd
+			current.Street, current.StreetNumber = splitStreet(parts[2])
+			current.City = parts[3]
+			current.PostalCode = parts[5]
+			current.AddressLine2 = strings.TrimSpace(strings.Join([]string{parts[0], parts[1]}, " "))
+			current.State = parts[4]
+			current.Country = parts[6]
+
+		case "FN":
+			if current.Lastname == "" {
+				fn := unescapeVCard(value)
+				if idx := strings.LastIndex(fn, " "); idx > 0 {
+					current.Firstname, current.Lastname = fn[:idx], fn[idx+1:]
+				} else {
+					current.Lastname = fn
+				}
+			}
+
+		case "N":
+			// Family;Given;Additional;Prefix;Suffix
+			parts := splitVCardValue(value, 5) //nolint:mnd
+			current.Lastname = parts[0]
+			current.Firstname = parts[1]
+			current.Title = parts[3]
+
+		case "ORG":
+			if current.Company == "" {
+				// Organization;Unit;...
+				current.Company = splitVCardValue(value, 1)[0]
+			}
+
+		case "EMAIL", "TEL", "TITLE":
+			if _, ok := current.Extra[name]; !ok {
+				current.setExtraIfSet(name, unescapeVCard(value))
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (p *Person) setExtraIfSet(key, value string) {
+	if value != "" {
+		p.setExtra(key, value)
+	}
+}
+
+// splitStreet separates the house number from the street supporting
+// both "Street 123" and "123 Street" notations
+func splitStreet(street string) (name, number string) {
+	street = strings.TrimSpace(street)
+
+	if m := streetNumberSuffix.FindStringSubmatch(street); m != nil {
+		return m[1], m[2]
+	}
+
+	if m := streetNumberPrefix.FindStringSubmatch(street); m != nil {
+		return m[2], m[1]
+	}
+
+	return street, ""
+}
+
+// splitVCardLine returns the upper-cased property name (without group
+// and parameters) and the raw value of the line
+func splitVCardLine(line string) (name, value string) {
+	head, value, _ := strings.Cut(line, ":")
+	name, _, _ = strings.Cut(head, ";")
+
+	name = strings.ToUpper(name)
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		// Strip group prefix (i.e. "item1.ADR")
+		name = name[idx+1:]
+	}
+
+	return name, value
+}
+
+// splitVCardValue splits a structured value at unescaped semicolons
+// and always returns n unescaped components
+func splitVCardValue(value string, n int) []string {
+	var (
+		parts   = make([]string, 0, n)
+		current strings.Builder
+		escaped bool
+	)
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	parts = append(parts, current.String())
+
+	for len(parts) < n {
+		parts = append(parts, "")
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(unescapeVCard(parts[i]))
+	}
+
+	return parts[:n]
+}
+
+func unescapeVCard(value string) string {
+	return strings.NewReplacer(
+		`\n`, "\n",
+		`\N`, "\n",
+		`\,`, ",",
+		`\;`, ";",
+		`\\`, `\`,
+	).Replace(value)
+}
+
+// unfoldVCardLines joins lines continued by a leading whitespace
+func unfoldVCardLines(content string) (lines []string) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,210 @@
+package recipientcsv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxMaxColumns is the number of columns supported by Excel (A to XFD)
+const xlsxMaxColumns = 16384
+
+type (
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	xlsxRichText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxSharedStrings struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+
+	xlsxSheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref       string       `xml:"r,attr"`
+				Type      string       `xml:"t,attr"`
+				Value     string       `xml:"v"`
+				InlineStr xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	xlsxWorkbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+)
+
+// ParseXLSX reads the first worksheet of the given Excel workbook,
+// treats its first row as headers and returns the Person data included
+// using the same mapping as for CSV files.
+func ParseXLSX(data io.Reader, opts Options) ([]Person, error) {
+	raw, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading data: %w", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("opening workbook: %w", err)
+	}
+
+	sheetPath, err := xlsxFirstSheet(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if err = xlsxDecode(zr, "xl/sharedStrings.xml", &sharedStrings); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading shared strings: %w", err)
+	}
+
+	var sheet xlsxSheet
+	if err = xlsxDecode(zr, sheetPath, &sheet); err != nil {
+		return nil, fmt.Errorf("reading worksheet: %w", err)
+	}
+
+	records := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				if col, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, fmt.Errorf("parsing cell reference: %w", err)
+				}
+			}
+
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string reference %q in cell %s", cell.Value, cell.Ref)
+				}
+				record[col] = sharedStrings.Items[idx].String()
+
+			case "inlineStr":
+				record[col] = cell.InlineStr.String()
+
+			default:
+				record[col] = cell.Value
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return fromRecords(records, opts)
+}
+
+func (x xlsxRichText) String() string {
+	if len(x.Runs) == 0 {
+		return x.Text
+	}
+
+	var sb strings.Builder
+	for _, r := range x.Runs {
+		sb.WriteString(r.Text)
+	}
+
+	return sb.String()
+}
+
+// xlsxColumnIndex converts the column letters of a cell reference
+// (i.e. "AB12") into a zero-based index
+func xlsxColumnIndex(ref string) (int, error) {
+	var col int
+
+	for _, r := range ref {
+		if r >= '0' && r <= '9' {
+			break
+		}
+
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+
+		col = col*26 + int(r-'A'+1) //nolint:mnd // Number of letters
+		if col > xlsxMaxColumns {
+			return 0, fmt.Errorf("cell reference %q exceeds the maximum column XFD", ref)
+		}
+	}
+
+	if col == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+
+	return col - 1, nil
+}
+
+func xlsxDecode(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", name, err)
+	}
+	defer f.Close() //nolint:errcheck // Read-only file in memory
+
+	if err = xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// xlsxFirstSheet resolves the path of the first worksheet in the
+// workbook through the workbook relationships
+func xlsxFirstSheet(zr *zip.Reader) (string, error) {
+	var (
+		rels     xlsxRelationships
+		workbook xlsxWorkbook
+	)
+
+	if err := xlsxDecode(zr, "xl/workbook.xml", &workbook); err != nil {
+		return "", fmt.Errorf("reading workbook: %w", err)
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("workbook contains no sheets")
+	}
+
+	if err := xlsxDecode(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", fmt.Errorf("reading workbook relationships: %w", err)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", fmt.Errorf("worksheet relationship %q not found", workbook.Sheets[0].RelID)
+}
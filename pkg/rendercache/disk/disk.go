@@ -0,0 +1,144 @@
+// Package disk implements a cache backend to hold the rendered
+// documents inside a directory on disk
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/rendercache"
+)
+
+type (
+	// Cache implements the rendercache.Cache interface for disk
+	// storage removing the oldest documents when exceeding the size
+	// limit
+	Cache struct {
+		dir     string
+		maxSize int64
+		ttl     time.Duration
+
+		lock sync.Mutex
+	}
+)
+
+var _ rendercache.Cache = (*Cache)(nil)
+
+// New creates a new disk cache inside the given directory holding up
+// to maxSize bytes of documents for at most the given ttl
+func New(dir string, maxSize int64, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		ttl:     ttl,
+	}, nil
+}
+
+// Get retrieves the document by its key and returns whether it
+// was found in the cache
+func (c *Cache) Get(key string) (content []byte, found bool, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	file := c.path(key)
+
+	info, err := os.Stat(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("getting file info: %w", err)
+	}
+
+	if time.Since(info.ModTime()) > c.ttl {
+		if err = os.Remove(file); err != nil {
+			return nil, false, fmt.Errorf("removing expired file: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	if content, err = os.ReadFile(file); err != nil { //#nosec:G304 // Key is a hex-encoded hash
+		return nil, false, fmt.Errorf("reading file: %w", err)
+	}
+
+	return content, true, nil
+}
+
+// Set stores the document under the given key
+func (c *Cache) Set(key string, content []byte) error {
+	if int64(len(content)) > c.maxSize {
+		// Would evict everything else and still not fit
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Write to a temporary file first to prevent serving partial files
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return c.evict()
+}
+
+// evict removes expired files and the oldest files until the size
+// of the cache is below the limit
+func (c *Cache) evict() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("listing cache directory: %w", err)
+	}
+
+	var (
+		infos []fs.FileInfo
+		size  int64
+	)
+
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if time.Since(info.ModTime()) > c.ttl {
+			if err = os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+				return fmt.Errorf("removing expired file: %w", err)
+			}
+			continue
+		}
+
+		infos = append(infos, info)
+		size += info.Size()
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().Before(infos[j].ModTime()) })
+
+	for i := 0; size > c.maxSize && i < len(infos); i++ {
+		if err = os.Remove(filepath.Join(c.dir, infos[i].Name())); err != nil {
+			return fmt.Errorf("removing file: %w", err)
+		}
+		size -= infos[i].Size()
+	}
+
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
@@ -0,0 +1,100 @@
+// Package mem implements a cache backend to hold the rendered
+// documents inside memory
+package mem
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Luzifer/doc-render/pkg/rendercache"
+)
+
+type (
+	// Cache implements the rendercache.Cache interface for Memory
+	// storage evicting the least recently used documents when
+	// exceeding the size limit
+	Cache struct {
+		entries map[string]*list.Element
+		lru     *list.List
+		maxSize int64
+		size    int64
+		ttl     time.Duration
+
+		lock sync.Mutex
+	}
+
+	entry struct {
+		content []byte
+		expires time.Time
+		key     string
+	}
+)
+
+var _ rendercache.Cache = (*Cache)(nil)
+
+// New creates a new memory cache holding up to maxSize bytes of
+// documents for at most the given ttl
+func New(maxSize int64, ttl time.Duration) *Cache {
+	return &Cache{
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+		maxSize: maxSize,
+		ttl:     ttl,
+	}
+}
+
+// Get retrieves the document by its key and returns whether it
+// was found in the cache
+func (c *Cache) Get(key string) (content []byte, found bool, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := elem.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+
+	c.lru.MoveToFront(elem)
+	return e.content, true, nil
+}
+
+// Set stores the document under the given key
+func (c *Cache) Set(key string, content []byte) error {
+	if int64(len(content)) > c.maxSize {
+		// Would evict everything else and still not fit
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{
+		content: content,
+		expires: time.Now().Add(c.ttl),
+		key:     key,
+	})
+	c.size += int64(len(content))
+
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+
+	return nil
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*entry)
+	delete(c.entries, e.key)
+	c.size -= int64(len(e.content))
+}
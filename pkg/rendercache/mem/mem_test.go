@@ -0,0 +1,41 @@
+package mem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := New(10, time.Hour)
+
+	require.NoError(t, c.Set("a", []byte("12345")))
+	require.NoError(t, c.Set("b", []byte("12345")))
+
+	// Access "a" to make "b" the least recently used
+	_, found, err := c.Get("a")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	require.NoError(t, c.Set("c", []byte("12345")))
+
+	_, found, _ = c.Get("b")
+	assert.False(t, found, "least recently used entry should be evicted")
+
+	content, found, _ := c.Get("a")
+	assert.True(t, found)
+	assert.Equal(t, []byte("12345"), content)
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := New(10, time.Millisecond)
+
+	require.NoError(t, c.Set("a", []byte("12345")))
+	time.Sleep(5 * time.Millisecond)
+
+	_, found, err := c.Get("a")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,38 @@
+// Package rendercache defines an interface to cache rendered documents
+// by a content-hash of their inputs
+package rendercache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+type (
+	// Cache defines the interface to implement when implementing a
+	// cache backend
+	Cache interface {
+		// Get retrieves the document by its key and returns whether it
+		// was found in the cache
+		Get(key string) (content []byte, found bool, err error)
+		// Set stores the document under the given key
+		Set(key string, content []byte) error
+	}
+)
+
+// Key generates the cache-key from the hash of the source-set and the
+// inputs of the render request. The inputs are JSON encoded to create
+// a stable representation (map keys are sorted).
+func Key(sourceHash string, inputs any) (string, error) {
+	payload, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("encoding inputs: %w", err)
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(sourceHash))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(payload)
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/Luzifer/doc-render/pkg/latex"
+	"github.com/Luzifer/doc-render/pkg/recipientcsv"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func openInputFile(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	f, err := os.Open(name) //#nosec:G304 // Intended to read user given file
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+
+	return f, nil
+}
+
+func readRenderValues() (values map[string]any, err error) {
+	if cfg.ValuesFile == "" {
+		return map[string]any{}, nil
+	}
+
+	f, err := openInputFile(cfg.ValuesFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening values file")
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.WithError(err).Error("closing values file")
+		}
+	}()
+
+	if err = json.NewDecoder(f).Decode(&values); err != nil {
+		return nil, errors.Wrap(err, "decoding values")
+	}
+
+	return values, nil
+}
+
+func readRenderRecipients(sourceSet string) (recipients []recipientcsv.Person, err error) {
+	if cfg.RecipientsFile == "" {
+		return []recipientcsv.Person{{}}, nil
+	}
+
+	f, err := openInputFile(cfg.RecipientsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening recipients file")
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.WithError(err).Error("closing recipients file")
+		}
+	}()
+
+	opts, err := latex.GetRecipientOptions(cfg.SourceSetFolder, sourceSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting recipient options")
+	}
+
+	if recipients, err = recipientcsv.ParseWithOptions(f, opts); err != nil {
+		return nil, errors.Wrap(err, "parsing recipients")
+	}
+
+	return recipients, nil
+}
+
+func runRender(renderer latex.Renderer, sourceSet string) (err error) {
+	if !latex.HasSourceSet(cfg.SourceSetFolder, sourceSet) {
+		return errors.Errorf("source-set %q not found", sourceSet)
+	}
+
+	if cfg.ValuesFile == "-" && cfg.RecipientsFile == "-" {
+		return errors.New("values and recipients cannot both be read from stdin")
+	}
+
+	schema, err := latex.GetSourceSet(cfg.SourceSetFolder, sourceSet)
+	if err != nil {
+		return errors.Wrap(err, "getting source-set definition")
+	}
+
+	values, err := readRenderValues()
+	if err != nil {
+		return errors.Wrap(err, "reading values")
+	}
+
+	values = latex.ApplyDefaults(schema, values)
+	if err = latex.ValidateValues(schema, values); err != nil {
+		return errors.Wrap(err, "validating values")
+	}
+
+	recipients, err := readRenderRecipients(sourceSet)
+	if err != nil {
+		return errors.Wrap(err, "reading recipients")
+	}
+
+	pdf, err := latex.Render(context.Background(), latex.RenderOpts{
+		Renderer: renderer,
+
+		SourceBaseFolder: cfg.SourceSetFolder,
+		SourceSet:        sourceSet,
+
+		Recipients: recipients,
+		Sandbox:    cfg.Sandbox,
+		Values:     values,
+	})
+	if err != nil {
+		var cErr latex.CompileError
+		if errors.As(err, &cErr) {
+			_, _ = os.Stderr.Write(cErr.Log)
+
+			for _, msg := range cErr.Messages {
+				logrus.WithFields(logrus.Fields{
+					"file":          msg.File,
+					"line":          msg.Line,
+					"template_line": msg.TemplateLine,
+				}).Error(msg.Message)
+			}
+		}
+		return errors.Wrap(err, "rendering PDF")
+	}
+	defer func() {
+		if err := pdf.Close(); err != nil {
+			logrus.WithError(err).Error("closing PDF reader")
+		}
+	}()
+
+	var out io.Writer = os.Stdout
+	if cfg.OutputFile != "-" {
+		f, err := os.Create(cfg.OutputFile)
+		if err != nil {
+			return errors.Wrap(err, "creating output file")
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				logrus.WithError(err).Error("closing output file")
+			}
+		}()
+		out = f
+	}
+
+	if _, err = io.Copy(out, pdf); err != nil {
+		return errors.Wrap(err, "writing PDF")
+	}
+
+	return nil
+}